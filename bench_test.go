@@ -0,0 +1,309 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// Payloads are generated on first use so that plain go test runs do not pay
+// for them. Run with e.g. go test -run XXX -bench . -benchmem
+var (
+	payloadOnce sync.Once
+	payloads    map[string][]byte
+)
+
+const (
+	// largeSize is the size of the big array, only lexed as a stream since
+	// keeping its tokens would need several GB
+	largeSize  = 100 << 20
+	mediumSize = 10 << 20
+	deepLevels = 5000
+)
+
+func benchPayloads() map[string][]byte {
+	payloadOnce.Do(func() {
+		payloads = map[string][]byte{
+			"small":  smallPayload(),
+			"medium": arrayPayload(mediumSize),
+			"large":  arrayPayload(largeSize),
+			"deep":   deepPayload(deepLevels),
+		}
+	})
+	return payloads
+}
+
+// smallPayload is a typical API response of about 1 KB
+func smallPayload() []byte {
+	var sb strings.Builder
+	sb.WriteString(`{"status": "ok", "page": 1, "per_page": 5, "total": 1234, "users": [`)
+	for i := 0; i < 5; i++ {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		fmt.Fprintf(&sb, `{"id": %d, "name": "User %d", "email": "user%d@example.com", "active": %v, "score": %d.%d, "roles": ["reader", "writer"], "manager": null}`,
+			1000+i, i, i, i%2 == 0, 90+i, i)
+	}
+	sb.WriteString(`], "links": {"next": "https://api.example.com/users?page=2", "prev": null}}`)
+	return []byte(sb.String())
+}
+
+// arrayPayload is an indented array of records of at least size bytes
+func arrayPayload(size int) []byte {
+	var buf bytes.Buffer
+	buf.Grow(size + 256)
+	buf.WriteString("[\n")
+	for i := 0; buf.Len() < size; i++ {
+		if i > 0 {
+			buf.WriteString(",\n")
+		}
+		fmt.Fprintf(&buf, `  {"id": %d, "name": "item %d", "price": %d.99, "tags": ["a", "b\"c"], "in_stock": true, "note": null}`, i, i, i%1000)
+	}
+	buf.WriteString("\n]")
+	return buf.Bytes()
+}
+
+func deepPayload(levels int) []byte {
+	return []byte(strings.Repeat(`{"a":[`, levels) + "1" + strings.Repeat("]}", levels))
+}
+
+func benchmarkPayloads(b *testing.B, names []string, fn func(b *testing.B, input []byte)) {
+	all := benchPayloads()
+	for _, name := range names {
+		input := all[name]
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(int64(len(input)))
+			b.ReportAllocs()
+			b.ResetTimer()
+			fn(b, input)
+		})
+	}
+}
+
+func lexAll(b *testing.B, l *Lexer) {
+	for {
+		t, err := l.Next()
+		if err != nil {
+			b.Fatal(err)
+		}
+		if t.TokenType == EOF {
+			return
+		}
+	}
+}
+
+func BenchmarkLexerNext(b *testing.B) {
+	benchmarkPayloads(b, []string{"small", "medium", "large", "deep"}, func(b *testing.B, input []byte) {
+		for i := 0; i < b.N; i++ {
+			lexAll(b, NewLexerBytes(input))
+		}
+	})
+}
+
+func BenchmarkLexerNextReader(b *testing.B) {
+	benchmarkPayloads(b, []string{"small", "medium", "large", "deep"}, func(b *testing.B, input []byte) {
+		for i := 0; i < b.N; i++ {
+			lexAll(b, NewLexer(bufio.NewReader(bytes.NewReader(input))))
+		}
+	})
+}
+
+func BenchmarkTokenize(b *testing.B) {
+	benchmarkPayloads(b, []string{"small", "medium", "deep"}, func(b *testing.B, input []byte) {
+		for i := 0; i < b.N; i++ {
+			if _, err := NewLexerBytes(input).Tokenize(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkParse(b *testing.B) {
+	benchmarkPayloads(b, []string{"small", "medium", "deep"}, func(b *testing.B, input []byte) {
+		for i := 0; i < b.N; i++ {
+			p, err := NewParser(input)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := p.Parse(); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+func BenchmarkParseValue(b *testing.B) {
+	benchmarkPayloads(b, []string{"small", "medium", "deep"}, func(b *testing.B, input []byte) {
+		for i := 0; i < b.N; i++ {
+			if _, err := ParseValue(input); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkStdValid is a reference point for the benchmarks above
+func BenchmarkStdValid(b *testing.B) {
+	benchmarkPayloads(b, []string{"small", "medium", "large", "deep"}, func(b *testing.B, input []byte) {
+		for i := 0; i < b.N; i++ {
+			if !json.Valid(input) {
+				b.Fatal("invalid payload")
+			}
+		}
+	})
+}
+
+// stringsPayload is an array of long strings, as in log lines
+func stringsPayload(size int) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("[\n")
+	for i := 0; buf.Len() < size; i++ {
+		if i > 0 {
+			buf.WriteString(",\n")
+		}
+		fmt.Fprintf(&buf, `    "2024-05-01T12:00:%02d.000Z INFO request %d handled in 12ms for /api/v1/users?page=%d with status 200 and user agent Mozilla/5.0 (X11; Linux x86_64)"`, i%60, i, i)
+	}
+	buf.WriteString("\n]")
+	return buf.Bytes()
+}
+
+// indentedPayload is a deeply indented document, mostly whitespace
+func indentedPayload(size int) []byte {
+	v, _ := ParseValue(arrayPayload(size / 4))
+	out, _ := Format([]byte(v.String()), FormatOptions{IndentWidth: 8})
+	return out
+}
+
+// BenchmarkSWAR compares the word-at-a-time scanning with the byte loops
+func BenchmarkSWAR(b *testing.B) {
+	inputs := []struct {
+		name  string
+		input []byte
+	}{
+		{"strings", stringsPayload(mediumSize)},
+		{"indented", indentedPayload(mediumSize)},
+	}
+
+	for _, in := range inputs {
+		for _, swar := range []bool{true, false} {
+			name := in.name + "/bytewise"
+			if swar {
+				name = in.name + "/swar"
+			}
+			b.Run(name, func(b *testing.B) {
+				useSWAR = swar
+				defer func() { useSWAR = true }()
+
+				b.SetBytes(int64(len(in.input)))
+				b.ReportAllocs()
+				b.ResetTimer()
+				for i := 0; i < b.N; i++ {
+					lexAll(b, NewLexerBytes(in.input))
+				}
+			})
+		}
+	}
+}
+
+func BenchmarkValid(b *testing.B) {
+	benchmarkPayloads(b, []string{"small", "medium", "large", "deep"}, func(b *testing.B, input []byte) {
+		for i := 0; i < b.N; i++ {
+			if !Valid(input) {
+				b.Fatal("invalid payload")
+			}
+		}
+	})
+}
+
+func BenchmarkValidReader(b *testing.B) {
+	benchmarkPayloads(b, []string{"small", "medium", "large", "deep"}, func(b *testing.B, input []byte) {
+		for i := 0; i < b.N; i++ {
+			if err := ValidReader(bytes.NewReader(input)); err != nil {
+				b.Fatal(err)
+			}
+		}
+	})
+}
+
+// BenchmarkParseRaw reads three fields of the medium payload
+func BenchmarkParseRaw(b *testing.B) {
+	benchmarkPayloads(b, []string{"small", "medium"}, func(b *testing.B, input []byte) {
+		for i := 0; i < b.N; i++ {
+			v, err := ParseRaw(input)
+			if err != nil {
+				b.Fatal(err)
+			}
+			for _, ptr := range []string{"/status", "/0/id", "/1/name", "/users/2/email"} {
+				v.Lookup(ptr)
+			}
+		}
+	})
+}
+
+// BenchmarkParseRawCost compares ParseRaw reading a few fields of every
+// payload with a single Valid pass over it
+func BenchmarkParseRawCost(b *testing.B) {
+	payloads := []string{"small", "medium", "deep"}
+	b.Run("valid", func(b *testing.B) {
+		benchmarkPayloads(b, payloads, func(b *testing.B, input []byte) {
+			for i := 0; i < b.N; i++ {
+				if !Valid(input) {
+					b.Fatal("invalid payload")
+				}
+			}
+		})
+	})
+	b.Run("raw", func(b *testing.B) {
+		benchmarkPayloads(b, payloads, func(b *testing.B, input []byte) {
+			for i := 0; i < b.N; i++ {
+				v, err := ParseRaw(input)
+				if err != nil {
+					b.Fatal(err)
+				}
+				for _, ptr := range []string{"/status", "/0/id", "/1/name", "/a/0/a/0/a"} {
+					v.Lookup(ptr)
+				}
+			}
+		})
+	})
+}
+
+// BenchmarkTapeLookup runs repeated queries against an indexed document
+func BenchmarkTapeLookup(b *testing.B) {
+	input := benchPayloads()["medium"]
+	tape, err := NewTape(input)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if tape.Lookup("/90000/tags/1") < 0 {
+			b.Fatal("missing value")
+		}
+	}
+}
+
+// BenchmarkValidateLines checks the records of the medium payload as JSON
+// Lines with one worker and with one per CPU
+func BenchmarkValidateLines(b *testing.B) {
+	input := benchPayloads()["medium"]
+	input = bytes.ReplaceAll(input[2:len(input)-2], []byte(",\n"), []byte("\n"))
+
+	for _, workers := range []int{1, 0} {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			b.SetBytes(int64(len(input)))
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				for rec := range ValidateLines(bytes.NewReader(input), workers) {
+					b.Fatal(rec.Err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package parser
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Canonicalize validates input and returns its RFC 8785 (JSON
+// Canonicalization Scheme) form: no whitespace, object members sorted by
+// the UTF-16 code units of their keys, numbers serialized like ECMAScript's
+// Number.prototype.toString and strings with only the mandatory escapes.
+// Input must be I-JSON (RFC 7493): duplicate keys, numbers outside the
+// IEEE 754 double range, invalid UTF-8 and unpaired surrogate escapes are
+// errors, as they would otherwise be replaced or dropped silently and two
+// different documents could get the same form.
+func Canonicalize(input []byte) ([]byte, error) {
+	p, err := NewParser(input)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.Parse(); err != nil {
+		return nil, err
+	}
+	for _, t := range p.tokens {
+		if t.TokenType == STRING {
+			if err := checkIJSONString(t.Value, t.Pos); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	v, err := p.Value()
+	if err != nil {
+		return nil, err
+	}
+
+	var sb strings.Builder
+	if err := writeCanonical(&sb, v); err != nil {
+		return nil, err
+	}
+	return []byte(sb.String()), nil
+}
+
+// checkIJSONString checks that the raw contents of a string token are
+// UTF-8 and that every surrogate escape is part of a pair
+func checkIJSONString(raw string, pos Position) error {
+	if !utf8.ValidString(raw) {
+		return fmt.Errorf("invalid UTF-8 in string at %v", pos)
+	}
+
+	for i := 0; i < len(raw); i++ {
+		if raw[i] != '\\' {
+			continue
+		}
+		// the lexer made sure escapes are complete
+		i++
+		if raw[i] != 'u' {
+			continue
+		}
+		r, _ := hex4(raw[i+1:])
+		i += 4
+		if !utf16.IsSurrogate(r) {
+			continue
+		}
+
+		if r < 0xdc00 && strings.HasPrefix(raw[i+1:], `\u`) {
+			if r2, ok := hex4(raw[i+3:]); ok && r2 >= 0xdc00 && r2 <= 0xdfff {
+				i += 6
+				continue
+			}
+		}
+		return fmt.Errorf("unpaired surrogate \\u%04x in string at %v", r, pos)
+	}
+	return nil
+}
+
+func writeCanonical(sb *strings.Builder, v *Value) error {
+	switch v.Kind {
+	case NUMBER_VALUE:
+		f, err := strconv.ParseFloat(v.Number, 64)
+		if err != nil {
+			return fmt.Errorf("number %s at %v cannot be represented as a double", v.Number, v.Pos)
+		}
+		sb.WriteString(formatES6Number(f))
+	case ARRAY_VALUE:
+		sb.WriteByte('[')
+		for i, e := range v.Elems {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			if err := writeCanonical(sb, e); err != nil {
+				return err
+			}
+		}
+		sb.WriteByte(']')
+	case OBJECT_VALUE:
+		members := make([]Member, len(v.Members))
+		copy(members, v.Members)
+
+		keys := make(map[string][]uint16, len(members))
+		for _, m := range members {
+			if _, ok := keys[m.Key]; ok {
+				return fmt.Errorf("duplicate key %s at %v", quote(m.Key), m.KeyPos)
+			}
+			keys[m.Key] = utf16.Encode([]rune(m.Key))
+		}
+
+		sort.Slice(members, func(a, b int) bool {
+			return compareUTF16(keys[members[a].Key], keys[members[b].Key]) < 0
+		})
+
+		sb.WriteByte('{')
+		for i, m := range members {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(quote(m.Key))
+			sb.WriteByte(':')
+			if err := writeCanonical(sb, m.Value); err != nil {
+				return err
+			}
+		}
+		sb.WriteByte('}')
+	default:
+		v.write(sb)
+	}
+	return nil
+}
+
+func compareUTF16(a, b []uint16) int {
+	for i := 0; i < len(a) && i < len(b); i++ {
+		if a[i] != b[i] {
+			if a[i] < b[i] {
+				return -1
+			}
+			return 1
+		}
+	}
+	return len(a) - len(b)
+}
+
+// formatES6Number formats a finite double the way ECMAScript's
+// Number.prototype.toString does: the shortest digits that round-trip,
+// in fixed notation for exponents from -7 to 20 and in exponent notation
+// otherwise
+func formatES6Number(f float64) string {
+	if f == 0 {
+		return "0" // also for -0
+	}
+
+	sign := ""
+	if f < 0 {
+		sign = "-"
+		f = -f
+	}
+
+	// shortest round-trip digits as d.ddde±x
+	e := strconv.FormatFloat(f, 'e', -1, 64)
+	mantissa, exp, _ := strings.Cut(e, "e")
+	digits := strings.Replace(mantissa, ".", "", 1)
+	x, _ := strconv.Atoi(exp)
+
+	k := len(digits)
+	n := x + 1 // position of the decimal point relative to the digits
+
+	switch {
+	case k <= n && n <= 21:
+		return sign + digits + strings.Repeat("0", n-k)
+	case 0 < n && n <= 21:
+		return sign + digits[:n] + "." + digits[n:]
+	case -6 < n && n <= 0:
+		return sign + "0." + strings.Repeat("0", -n) + digits
+	}
+
+	expSign := "+"
+	if n-1 < 0 {
+		expSign = "-"
+	}
+	frac := ""
+	if k > 1 {
+		frac = "." + digits[1:]
+	}
+	return sign + digits[:1] + frac + "e" + expSign + strconv.Itoa(int(math.Abs(float64(n-1))))
+}
@@ -0,0 +1,117 @@
+package parser
+
+import (
+	"math"
+	"testing"
+)
+
+// examples from RFC 8785 section 3.2
+
+func TestCanonicalizeRFCExample(t *testing.T) {
+	sample := []byte(`{
+  "numbers": [333333333.33333329, 1E30, 4.50,
+              2e-3, 0.000000000000000000000000001],
+  "string": "\u20ac$\u000F\u000aA'\u0042\u0022\u005c\\\"\/",
+  "literals": [null, true, false]
+}`)
+
+	out, err := Canonicalize(sample)
+	if err != nil {
+		t.Fatalf("error canonicalizing %v", err)
+	}
+
+	want := `{"literals":[null,true,false],"numbers":[333333333.3333333,1e+30,4.5,0.002,1e-27],"string":"€$\u000f\nA'B\"\\\\\"/"}`
+	if string(out) != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, out)
+	}
+}
+
+func TestCanonicalizeKeyOrder(t *testing.T) {
+	sample := []byte(`{
+  "\u20ac": "Euro Sign",
+  "\r": "Carriage Return",
+  "\ufb33": "Hebrew Letter Dalet With Dagesh",
+  "1": "One",
+  "\ud83d\ude00": "Emoji: Grinning Face",
+  "\u0080": "Control",
+  "\u00f6": "Latin Small Letter O With Diaeresis"
+}`)
+
+	out, err := Canonicalize(sample)
+	if err != nil {
+		t.Fatalf("error canonicalizing %v", err)
+	}
+
+	want := "{\"\\r\":\"Carriage Return\",\"1\":\"One\",\"\u0080\":\"Control\",\"ö\":\"Latin Small Letter O With Diaeresis\"," +
+		"\"€\":\"Euro Sign\",\"😀\":\"Emoji: Grinning Face\",\"\ufb33\":\"Hebrew Letter Dalet With Dagesh\"}"
+	if string(out) != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, out)
+	}
+}
+
+// number serialization samples from RFC 8785 appendix B
+func TestFormatES6Number(t *testing.T) {
+	cases := map[uint64]string{
+		0x0000000000000000: "0",
+		0x8000000000000000: "0",
+		0x0000000000000001: "5e-324",
+		0x8000000000000001: "-5e-324",
+		0x7fefffffffffffff: "1.7976931348623157e+308",
+		0xffefffffffffffff: "-1.7976931348623157e+308",
+		0x4340000000000000: "9007199254740992",
+		0xc340000000000000: "-9007199254740992",
+		0x4430000000000000: "295147905179352830000",
+		0x44b52d02c7e14af5: "9.999999999999997e+22",
+		0x44b52d02c7e14af6: "1e+23",
+		0x44b52d02c7e14af7: "1.0000000000000001e+23",
+		0x444b1ae4d6e2ef4e: "999999999999999700000",
+		0x444b1ae4d6e2ef4f: "999999999999999900000",
+		0x444b1ae4d6e2ef50: "1e+21",
+		0x3eb0c6f7a0b5ed8c: "9.999999999999997e-7",
+		0x3eb0c6f7a0b5ed8d: "0.000001",
+		0x41b3de4355555553: "333333333.3333332",
+		0x41b3de4355555554: "333333333.33333325",
+		0x41b3de4355555555: "333333333.3333333",
+		0x41b3de4355555556: "333333333.3333334",
+		0x41b3de4355555557: "333333333.33333343",
+		0xbecbf647612f3696: "-0.0000033333333333333333",
+		0x43143ff3c1cb0959: "1424953923781206.2",
+	}
+
+	for bits, want := range cases {
+		if got := formatES6Number(math.Float64frombits(bits)); got != want {
+			t.Errorf("%016x: expected %s, got %s", bits, want, got)
+		}
+	}
+}
+
+func TestCanonicalizeErrors(t *testing.T) {
+	samples := []string{
+		`{"a": 1, "a": 2}`,
+		`[1e400]`,
+		`[1, 2`,
+		`{"b": "\ud800"}`,
+		`{"b": "\udc00"}`,
+		`{"b": "x\ud800\u0041"}`,
+		`{"b": "\udc00\ud800"}`,
+		`{"\ud83d": 1}`,
+		"{\"b\": \"\xff\"}",
+		"[\"\xed\xa0\x80\"]",
+	}
+
+	for _, s := range samples {
+		if _, err := Canonicalize([]byte(s)); err == nil {
+			t.Errorf("%s: error should have been raised", s)
+		}
+	}
+}
+
+func TestCanonicalizeSurrogatePairs(t *testing.T) {
+	got, err := Canonicalize([]byte(`["\ud83d\ude00", "\\ud800", "\u00e9"]`))
+	if err != nil {
+		t.Fatalf("error canonicalizing %v", err)
+	}
+	if want := `["😀","\\ud800","é"]`; string(got) != want {
+		t.Errorf("expected %s, got %s", want, got)
+	}
+}
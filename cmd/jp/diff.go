@@ -0,0 +1,111 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	parser "github.com/Re1nGer/go_jp"
+)
+
+type pathList []string
+
+func (p *pathList) String() string {
+	return strings.Join(*p, ",")
+}
+
+func (p *pathList) Set(s string) error {
+	*p = append(*p, s)
+	return nil
+}
+
+type diffChange struct {
+	Type string    `json:"type"`
+	Path string    `json:"path"`
+	From *diffSide `json:"from,omitempty"`
+	To   *diffSide `json:"to,omitempty"`
+}
+
+type diffSide struct {
+	Value *parser.Value `json:"value"`
+	Line  int           `json:"line"`
+	Col   int           `json:"col"`
+}
+
+func runDiff(args []string) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	var ignore pathList
+	fs.Var(&ignore, "ignore", "JSON Pointer to leave out of the comparison (repeatable)")
+	ignoreOrder := fs.Bool("ignore-order", false, "compare arrays regardless of element order")
+	tolerance := fs.Float64("tolerance", 0, "largest difference at which numbers are equal")
+	color := fs.String("color", "auto", "color the output: auto, always or never")
+	asJSON := fs.Bool("json", false, "print changes as JSON")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: jp diff [flags] <a.json> <b.json>")
+		fs.PrintDefaults()
+	}
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return exitError
+	}
+
+	if len(args) != 2 {
+		fs.Usage()
+		return exitError
+	}
+
+	a, err := readInput(args[0])
+	if err != nil {
+		errorf("%v", err)
+		return exitError
+	}
+	b, err := readInput(args[1])
+	if err != nil {
+		errorf("%v", err)
+		return exitError
+	}
+
+	changes, err := parser.DiffWithOptions(a, b, parser.DiffOptions{
+		IgnoreArrayOrder: *ignoreOrder,
+		IgnorePaths:      ignore,
+		NumericTolerance: *tolerance,
+	})
+	if err != nil {
+		errorf("%v", err)
+		return exitError
+	}
+
+	if *asJSON {
+		out := make([]diffChange, 0, len(changes))
+		for _, c := range changes {
+			out = append(out, diffChange{Type: c.Type.String(), Path: c.Path, From: side(c.From), To: side(c.To)})
+		}
+		writeJSON(out)
+	} else {
+		parser.WriteDiff(os.Stdout, changes, useColor(*color))
+	}
+
+	if len(changes) > 0 {
+		return exitFailure
+	}
+	return exitOK
+}
+
+func side(v *parser.Value) *diffSide {
+	if v == nil {
+		return nil
+	}
+	return &diffSide{Value: v, Line: v.Pos.Line, Col: v.Pos.Col}
+}
+
+func useColor(mode string) bool {
+	switch mode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+	info, err := os.Stdout.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0 && os.Getenv("NO_COLOR") == ""
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+
+	parser "github.com/Re1nGer/go_jp"
+)
+
+func runFmt(args []string) int {
+	fs := flag.NewFlagSet("fmt", flag.ContinueOnError)
+	indent := fs.Int("indent", 2, "spaces per indentation level")
+	tabs := fs.Bool("tabs", false, "indent with tabs")
+	sortKeys := fs.Bool("sort", false, "sort object keys")
+	width := fs.Int("width", 80, "keep arrays of scalars on one line up to this width, 0 to always break")
+	write := fs.Bool("w", false, "write the result back to the files")
+	list := fs.Bool("l", false, "list files whose formatting differs")
+	pool := addPoolFlags(fs)
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return exitError
+	}
+
+	opts := parser.FormatOptions{
+		UseTabs:      *tabs,
+		IndentWidth:  *indent,
+		SortKeys:     *sortKeys,
+		LineWidth:    *width,
+		FinalNewline: true,
+	}
+
+	return rewriteFiles(args, pool, *write, *list, func(content []byte) ([]byte, error) {
+		return parser.Format(content, opts)
+	})
+}
+
+// rewriteResult is the outcome of transforming a single file
+type rewriteResult struct {
+	file    string
+	out     []byte
+	changed bool
+	err     error
+	// code is the exit code the file contributes
+	code int
+}
+
+func (r rewriteResult) outcome() string {
+	switch {
+	case r.code == exitError:
+		return "error"
+	case r.err != nil:
+		return "invalid"
+	case r.changed:
+		return "changed"
+	}
+	return "unchanged"
+}
+
+// rewriteFiles applies transform to every input and either prints the
+// result, writes it back in place or lists the files it would change
+func rewriteFiles(args []string, pool *poolFlags, write, list bool, transform func([]byte) ([]byte, error)) int {
+	files, err := expandInputs(args)
+	if err != nil {
+		errorf("%v", err)
+		return exitError
+	}
+
+	// files are read, transformed and written back by the workers, only
+	// printing happens in order
+	rewrite := func(file string) rewriteResult {
+		res := rewriteResult{file: file}
+
+		content, err := readInput(file)
+		if err != nil {
+			res.err, res.code = err, exitError
+			return res
+		}
+
+		res.out, err = transform(content)
+		if err != nil {
+			res.err, res.code = fmt.Errorf("%s: %v", file, err), exitFailure
+			return res
+		}
+		res.changed = !bytes.Equal(content, res.out)
+
+		if write && file != "-" && res.changed {
+			if err := os.WriteFile(file, res.out, 0o644); err != nil {
+				res.err, res.code = err, exitError
+			}
+		}
+		return res
+	}
+
+	sum := newSummary("unchanged", "changed", "invalid", "error")
+	code := exitOK
+
+	forEachFile(files, *pool.jobs, *pool.unordered, rewrite, func(res rewriteResult) {
+		sum.add(res.outcome())
+		code = max(code, res.code)
+
+		switch {
+		case res.code == exitError:
+			errorf("%v", res.err)
+			return
+		case res.err != nil:
+			fmt.Fprintln(os.Stderr, res.err)
+			return
+		}
+
+		if list && res.changed {
+			fmt.Println(res.file)
+		}
+		if !list && (!write || res.file == "-") {
+			os.Stdout.Write(res.out)
+		}
+	})
+
+	if *pool.summary {
+		sum.write(os.Stderr, *pool.jobs)
+	}
+	return code
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"os"
+
+	parser "github.com/Re1nGer/go_jp"
+)
+
+// runGoStruct reads JSON samples and prints Go struct definitions that can
+// hold all of them
+func runGoStruct(args []string) int {
+	fs := flag.NewFlagSet("gostruct", flag.ContinueOnError)
+	name := fs.String("name", "Root", "name of the top level type")
+	pkg := fs.String("pkg", "", "package clause to emit, none if empty")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return exitError
+	}
+
+	files, err := expandInputs(args)
+	if err != nil {
+		errorf("%v", err)
+		return exitError
+	}
+
+	samples := make([][]byte, 0, len(files))
+	for _, file := range files {
+		content, err := readInput(file)
+		if err != nil {
+			errorf("%v", err)
+			return exitError
+		}
+		samples = append(samples, content)
+	}
+
+	src, err := parser.GenerateGoStructs(parser.GoStructOptions{Package: *pkg, TypeName: *name}, samples...)
+	if err != nil {
+		errorf("%v", err)
+		return exitFailure
+	}
+
+	os.Stdout.Write(src)
+	return exitOK
+}
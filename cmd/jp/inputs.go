@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// expandInputs turns command line arguments into a list of files. No
+// arguments means stdin.
+func expandInputs(args []string) ([]string, error) {
+	if len(args) == 0 {
+		return []string{"-"}, nil
+	}
+
+	var files []string
+	for _, arg := range args {
+		switch {
+		case arg == "-":
+			files = append(files, arg)
+		case hasMeta(arg):
+			matches, err := expandPattern(arg)
+			if err != nil {
+				return nil, err
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no files match %s", arg)
+			}
+			files = append(files, matches...)
+		default:
+			info, err := os.Stat(arg)
+			if err != nil {
+				return nil, err
+			}
+			if !info.IsDir() {
+				files = append(files, arg)
+				continue
+			}
+			matches, err := expandPattern(filepath.Join(arg, "**", "*.json"))
+			if err != nil {
+				return nil, err
+			}
+			files = append(files, matches...)
+		}
+	}
+	return files, nil
+}
+
+func hasMeta(s string) bool {
+	return strings.ContainsAny(s, "*?[")
+}
+
+// expandPattern walks the directory before the first wildcard and returns
+// the files matching pattern, where a ** segment matches any number of
+// directories
+func expandPattern(pattern string) ([]string, error) {
+	segs := strings.Split(filepath.ToSlash(pattern), "/")
+
+	i := 0
+	for i < len(segs) && !hasMeta(segs[i]) {
+		i++
+	}
+
+	root := strings.Join(segs[:i], "/")
+	switch {
+	case root == "" && i > 0:
+		root = "/"
+	case root == "":
+		root = "."
+	}
+
+	var matches []string
+	err := filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+
+		rel := filepath.ToSlash(p)
+		if root != "." {
+			rel = strings.TrimPrefix(strings.TrimPrefix(rel, strings.TrimSuffix(root, "/")), "/")
+		}
+
+		if matchSegments(segs[i:], strings.Split(rel, "/")) {
+			matches = append(matches, p)
+		}
+		return nil
+	})
+
+	return matches, err
+}
+
+func matchSegments(pattern, name []string) bool {
+	if len(pattern) == 0 {
+		return len(name) == 0
+	}
+
+	if pattern[0] == "**" {
+		for k := 0; k <= len(name); k++ {
+			if matchSegments(pattern[1:], name[k:]) {
+				return true
+			}
+		}
+		return false
+	}
+
+	if len(name) == 0 {
+		return false
+	}
+
+	ok, _ := path.Match(pattern[0], name[0])
+	return ok && matchSegments(pattern[1:], name[1:])
+}
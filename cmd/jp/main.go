@@ -0,0 +1,124 @@
+// Command jp validates, formats, minifies, queries, compares and inspects
+// JSON documents.
+//
+// Usage:
+//
+//	jp <command> [flags] [files...]
+//
+// Files may be paths, directories (searched recursively for *.json), glob
+// patterns with ** matching any number of directories, or "-" for stdin.
+// Without files, commands read stdin. validate, fmt and minify work on
+// several files at once, see -j.
+//
+// Exit codes: 0 on success, 1 if a document is invalid, differs or a query
+// has no result, 2 on usage or I/O errors.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	exitOK      = 0
+	exitFailure = 1
+	exitError   = 2
+)
+
+type command struct {
+	name  string
+	usage string
+	run   func(args []string) int
+}
+
+var commands = []command{
+	{"validate", "check that documents are valid JSON, optionally against a schema", runValidate},
+	{"fmt", "pretty-print documents", runFmt},
+	{"minify", "remove all insignificant whitespace", runMinify},
+	{"query", "print the value at a JSON Pointer", runQuery},
+	{"diff", "compare two documents", runDiff},
+	{"stats", "count values, keys and nesting depth", runStats},
+	{"gostruct", "generate Go struct definitions from samples", runGoStruct},
+}
+
+func main() {
+	os.Exit(run(os.Args[1:]))
+}
+
+func run(args []string) int {
+	if len(args) == 0 {
+		usage(os.Stderr)
+		return exitError
+	}
+
+	if args[0] == "help" || args[0] == "-h" || args[0] == "-help" || args[0] == "--help" {
+		usage(os.Stdout)
+		return exitOK
+	}
+
+	for _, c := range commands {
+		if c.name == args[0] {
+			return c.run(args[1:])
+		}
+	}
+
+	fmt.Fprintf(os.Stderr, "jp: unknown command %q\n", args[0])
+	usage(os.Stderr)
+	return exitError
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: jp <command> [flags] [files...]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+	for _, c := range commands {
+		fmt.Fprintf(w, "  %-10s %s\n", c.name, c.usage)
+	}
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'jp <command> -h' for the flags of a command.")
+}
+
+// readInput reads a file, or stdin for "-"
+func readInput(name string) ([]byte, error) {
+	if name == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+	return os.ReadFile(name)
+}
+
+func writeJSON(v any) {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.Encode(v)
+}
+
+func errorf(format string, args ...any) {
+	fmt.Fprintf(os.Stderr, "jp: "+format+"\n", args...)
+}
+
+// parseFlags parses fs allowing flags to be mixed with positional arguments,
+// e.g. "jp query /a file.json -r", and returns the positional ones
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+
+		// everything after "--" is positional
+		if consumed := len(args) - len(rest); consumed > 0 && args[consumed-1] == "--" {
+			return append(positional, rest...), nil
+		}
+
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
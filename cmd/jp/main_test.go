@@ -0,0 +1,192 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestMatchSegments(t *testing.T) {
+	cases := []struct {
+		pattern, name string
+		ok            bool
+	}{
+		{"**/*.json", "a.json", true},
+		{"**/*.json", "a/b/c.json", true},
+		{"a/**/c.json", "a/c.json", true},
+		{"a/**/c.json", "a/x/y/c.json", true},
+		{"a/*.json", "a/b/c.json", false},
+		{"*.json", "a.txt", false},
+	}
+
+	for _, c := range cases {
+		got := matchSegments(strings.Split(c.pattern, "/"), strings.Split(c.name, "/"))
+		if got != c.ok {
+			t.Errorf("%s against %s: expected %v", c.pattern, c.name, c.ok)
+		}
+	}
+}
+
+func TestExpandInputs(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"a.json", "sub/b.json", "sub/deep/c.json", "sub/skip.txt"} {
+		path := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(path), 0o755)
+		os.WriteFile(path, []byte("{}"), 0o644)
+	}
+
+	files, err := expandInputs([]string{dir})
+	if err != nil {
+		t.Fatalf("error expanding %v", err)
+	}
+	if len(files) != 3 {
+		t.Errorf("expected 3 files, got %v", files)
+	}
+
+	files, err = expandInputs([]string{filepath.Join(dir, "sub", "**", "*.json")})
+	if err != nil {
+		t.Fatalf("error expanding %v", err)
+	}
+	want := []string{filepath.Join(dir, "sub", "b.json"), filepath.Join(dir, "sub", "deep", "c.json")}
+	if !reflect.DeepEqual(files, want) {
+		t.Errorf("expected %v, got %v", want, files)
+	}
+
+	if _, err := expandInputs([]string{filepath.Join(dir, "*.yaml")}); err == nil {
+		t.Errorf("error should have been raised")
+	}
+}
+
+func TestParseFlags(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	raw := fs.Bool("r", false, "")
+
+	args, err := parseFlags(fs, []string{"/a", "file.json", "-r", "--", "-x"})
+	if err != nil {
+		t.Fatalf("error parsing flags %v", err)
+	}
+
+	if !*raw || !reflect.DeepEqual(args, []string{"/a", "file.json", "-x"}) {
+		t.Errorf("unexpected result %v %v", *raw, args)
+	}
+}
+
+func TestExitCodes(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.json")
+	invalid := filepath.Join(dir, "invalid.json")
+	os.WriteFile(valid, []byte(`{"a": [1, 2]}`), 0o644)
+	os.WriteFile(invalid, []byte(`{"a": [1, 2}`), 0o644)
+
+	stdout := os.Stdout
+	os.Stdout, _ = os.Open(os.DevNull)
+	defer func() { os.Stdout = stdout }()
+
+	cases := []struct {
+		args []string
+		code int
+	}{
+		{[]string{"validate", valid}, exitOK},
+		{[]string{"validate", valid, invalid}, exitFailure},
+		{[]string{"query", "/a/1", valid}, exitOK},
+		{[]string{"query", "/b", valid}, exitFailure},
+		{[]string{"diff", valid, valid}, exitOK},
+		{[]string{"validate", filepath.Join(dir, "missing.json")}, exitError},
+		{[]string{"validate", "-j", "4", valid, invalid, valid}, exitFailure},
+		{[]string{"validate", "-j", "4", valid, filepath.Join(dir, "missing.json"), invalid}, exitError},
+		{[]string{"fmt", "-j", "2", "-l", valid, invalid}, exitFailure},
+		{[]string{"unknown"}, exitError},
+	}
+
+	for _, c := range cases {
+		if code := run(c.args); code != c.code {
+			t.Errorf("%v: expected exit code %d, got %d", c.args, c.code, code)
+		}
+	}
+}
+
+func TestForEachFileOrder(t *testing.T) {
+	files := make([]string, 50)
+	for i := range files {
+		files[i] = fmt.Sprint(i)
+	}
+
+	// later files finish first, the output must still follow the input
+	work := func(file string) string {
+		n := len(files) - len(file)
+		for i := 0; i < n*1000; i++ {
+			runtime.Gosched()
+		}
+		return file
+	}
+
+	var got []string
+	forEachFile(files, 8, false, work, func(s string) { got = append(got, s) })
+	if !reflect.DeepEqual(got, files) {
+		t.Errorf("expected %v, got %v", files, got)
+	}
+
+	got = got[:0]
+	forEachFile(files, 8, true, work, func(s string) { got = append(got, s) })
+	if len(got) != len(files) {
+		t.Errorf("expected %d results, got %d", len(files), len(got))
+	}
+}
+
+func TestSummary(t *testing.T) {
+	sum := newSummary("ok", "invalid", "error")
+	sum.add("ok")
+	sum.add("ok")
+	sum.add("invalid")
+
+	var b strings.Builder
+	sum.write(&b, 4)
+
+	lines := strings.Split(b.String(), "\n")
+	if !strings.HasPrefix(lines[0], "ok") || !strings.HasSuffix(lines[0], " 2") {
+		t.Errorf("unexpected line %q", lines[0])
+	}
+	if !strings.HasSuffix(lines[2], " 0") {
+		t.Errorf("unexpected line %q", lines[2])
+	}
+	if !strings.Contains(lines[3], "3 in") || !strings.Contains(lines[3], "with 4 jobs") {
+		t.Errorf("unexpected line %q", lines[3])
+	}
+}
+
+func TestValidateJSONOutput(t *testing.T) {
+	dir := t.TempDir()
+	valid := filepath.Join(dir, "valid.json")
+	invalid := filepath.Join(dir, "invalid.json")
+	os.WriteFile(valid, []byte(`{"a": [], "b": {"c": [1, {}]}}`), 0o644)
+	os.WriteFile(invalid, []byte(`[1, 2`), 0o644)
+
+	out, err := os.Create(filepath.Join(dir, "out"))
+	if err != nil {
+		t.Fatalf("error creating output %v", err)
+	}
+	stdout := os.Stdout
+	os.Stdout = out
+	code := run([]string{"validate", "-json", valid, invalid})
+	os.Stdout = stdout
+	out.Close()
+
+	if code != exitFailure {
+		t.Errorf("expected exit code %d, got %d", exitFailure, code)
+	}
+
+	content, _ := os.ReadFile(out.Name())
+	var results []map[string]any
+	if err := json.Unmarshal(content, &results); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, content)
+	}
+	if len(results) != 2 || results[0]["valid"] != true || results[1]["valid"] != false {
+		t.Errorf("unexpected results %s", content)
+	}
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+
+	parser "github.com/Re1nGer/go_jp"
+)
+
+func runMinify(args []string) int {
+	fs := flag.NewFlagSet("minify", flag.ContinueOnError)
+	write := fs.Bool("w", false, "write the result back to the files")
+	pool := addPoolFlags(fs)
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return exitError
+	}
+
+	// stdin is streamed so large inputs do not have to fit in memory
+	if len(args) == 0 && !*write {
+		if err := parser.Minify(os.Stdout, os.Stdin); err != nil {
+			fmt.Fprintf(os.Stderr, "-: %v\n", err)
+			return exitFailure
+		}
+		fmt.Println()
+		return exitOK
+	}
+
+	return rewriteFiles(args, pool, *write, false, func(content []byte) ([]byte, error) {
+		var out bytes.Buffer
+		if err := parser.MinifyBuffered(&out, bytes.NewReader(content)); err != nil {
+			return nil, err
+		}
+		out.WriteByte('\n')
+		return out.Bytes(), nil
+	})
+}
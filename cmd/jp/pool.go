@@ -0,0 +1,110 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"runtime"
+	"sync"
+	"time"
+)
+
+// poolFlags are the flags of commands that process many files at once
+type poolFlags struct {
+	jobs      *int
+	unordered *bool
+	summary   *bool
+}
+
+func addPoolFlags(fs *flag.FlagSet) *poolFlags {
+	return &poolFlags{
+		jobs:      fs.Int("j", runtime.GOMAXPROCS(0), "number of files to process concurrently"),
+		unordered: fs.Bool("unordered", false, "print results as files finish instead of in input order"),
+		summary:   fs.Bool("summary", false, "print a summary table to stderr"),
+	}
+}
+
+// forEachFile runs work on every file with up to jobs goroutines and hands
+// each result to emit on the calling goroutine, so emit may write output
+// without locking. Results arrive in the order of files unless unordered
+// is set.
+func forEachFile[T any](files []string, jobs int, unordered bool, work func(string) T, emit func(T)) {
+	jobs = max(1, min(jobs, len(files)))
+
+	type result struct {
+		i   int
+		val T
+	}
+
+	next := make(chan int)
+	results := make(chan result, jobs)
+
+	var wg sync.WaitGroup
+	for w := 0; w < jobs; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range next {
+				results <- result{i, work(files[i])}
+			}
+		}()
+	}
+
+	go func() {
+		for i := range files {
+			next <- i
+		}
+		close(next)
+		wg.Wait()
+		close(results)
+	}()
+
+	// in order mode, results that finish early wait here for their turn
+	pending := map[int]T{}
+	want := 0
+	for r := range results {
+		if unordered {
+			emit(r.val)
+			continue
+		}
+
+		pending[r.i] = r.val
+		for {
+			val, ok := pending[want]
+			if !ok {
+				break
+			}
+			delete(pending, want)
+			emit(val)
+			want++
+		}
+	}
+}
+
+// summary counts files by outcome for the table printed with -summary
+type summary struct {
+	start  time.Time
+	order  []string
+	counts map[string]int
+}
+
+// newSummary lists the outcomes in the order the table shows them
+func newSummary(outcomes ...string) *summary {
+	return &summary{start: time.Now(), order: outcomes, counts: map[string]int{}}
+}
+
+func (s *summary) add(outcome string) {
+	s.counts[outcome]++
+}
+
+func (s *summary) write(w io.Writer, jobs int) {
+	total := 0
+	for _, o := range s.order {
+		fmt.Fprintf(w, "%-10s %8d\n", o, s.counts[o])
+		total += s.counts[o]
+	}
+
+	elapsed := time.Since(s.start)
+	fmt.Fprintf(w, "%-10s %8d in %v with %d jobs (%.0f files/s)\n", "total", total,
+		elapsed.Round(time.Millisecond), jobs, float64(total)/elapsed.Seconds())
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	parser "github.com/Re1nGer/go_jp"
+)
+
+func runQuery(args []string) int {
+	fs := flag.NewFlagSet("query", flag.ContinueOnError)
+	raw := fs.Bool("r", false, "print strings without quotes")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "Usage: jp query [flags] <json-pointer> [files...]")
+		fs.PrintDefaults()
+	}
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return exitError
+	}
+
+	if len(args) < 1 {
+		fs.Usage()
+		return exitError
+	}
+	ptr := args[0]
+
+	files, err := expandInputs(args[1:])
+	if err != nil {
+		errorf("%v", err)
+		return exitError
+	}
+
+	code := exitOK
+	for _, file := range files {
+		content, err := readInput(file)
+		if err != nil {
+			errorf("%v", err)
+			code = exitError
+			continue
+		}
+
+		v, err := parser.ParseValue(content)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", file, err)
+			code = max(code, exitFailure)
+			continue
+		}
+
+		found := v.Lookup(ptr)
+		if found == nil {
+			fmt.Fprintf(os.Stderr, "%s: no value at %q\n", file, ptr)
+			code = max(code, exitFailure)
+			continue
+		}
+
+		if *raw && found.Kind == parser.STRING_VALUE {
+			fmt.Println(found.Str)
+		} else {
+			fmt.Println(found.String())
+		}
+	}
+	return code
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	parser "github.com/Re1nGer/go_jp"
+)
+
+type stats struct {
+	File     string `json:"file"`
+	Bytes    int    `json:"bytes"`
+	Objects  int    `json:"objects"`
+	Arrays   int    `json:"arrays"`
+	Strings  int    `json:"strings"`
+	Numbers  int    `json:"numbers"`
+	Booleans int    `json:"booleans"`
+	Nulls    int    `json:"nulls"`
+	Keys     int    `json:"keys"`
+	MaxDepth int    `json:"max_depth"`
+}
+
+func runStats(args []string) int {
+	fs := flag.NewFlagSet("stats", flag.ContinueOnError)
+	asJSON := fs.Bool("json", false, "print statistics as JSON")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return exitError
+	}
+
+	files, err := expandInputs(args)
+	if err != nil {
+		errorf("%v", err)
+		return exitError
+	}
+
+	all := make([]stats, 0, len(files))
+	code := exitOK
+	for _, file := range files {
+		content, err := readInput(file)
+		if err != nil {
+			errorf("%v", err)
+			code = exitError
+			continue
+		}
+
+		v, err := parser.ParseValue(content)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", file, err)
+			code = max(code, exitFailure)
+			continue
+		}
+
+		s := stats{File: file, Bytes: len(content)}
+		s.count(v, 0)
+		all = append(all, s)
+	}
+
+	if *asJSON {
+		writeJSON(all)
+		return code
+	}
+
+	for _, s := range all {
+		fmt.Printf("%s: %d bytes, depth %d, %d objects, %d arrays, %d keys, %d strings, %d numbers, %d booleans, %d nulls\n",
+			s.File, s.Bytes, s.MaxDepth, s.Objects, s.Arrays, s.Keys, s.Strings, s.Numbers, s.Booleans, s.Nulls)
+	}
+	return code
+}
+
+// count adds v and its children, depth is the number of containers around v
+func (s *stats) count(v *parser.Value, depth int) {
+	if v.Kind == parser.OBJECT_VALUE || v.Kind == parser.ARRAY_VALUE {
+		s.MaxDepth = max(s.MaxDepth, depth+1)
+	}
+
+	switch v.Kind {
+	case parser.OBJECT_VALUE:
+		s.Objects++
+		s.Keys += len(v.Members)
+		for _, m := range v.Members {
+			s.count(m.Value, depth+1)
+		}
+	case parser.ARRAY_VALUE:
+		s.Arrays++
+		for _, e := range v.Elems {
+			s.count(e, depth+1)
+		}
+	case parser.STRING_VALUE:
+		s.Strings++
+	case parser.NUMBER_VALUE:
+		s.Numbers++
+	case parser.BOOL_VALUE:
+		s.Booleans++
+	case parser.NULL_VALUE:
+		s.Nulls++
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+
+	parser "github.com/Re1nGer/go_jp"
+)
+
+type validateResult struct {
+	File       string               `json:"file"`
+	Valid      bool                 `json:"valid"`
+	Error      string               `json:"error,omitempty"`
+	Violations []parser.SchemaError `json:"violations,omitempty"`
+	// readFailed is set when the file could not be read at all
+	readFailed bool
+}
+
+func (r validateResult) outcome() string {
+	switch {
+	case r.readFailed:
+		return "error"
+	case r.Valid:
+		return "ok"
+	}
+	return "invalid"
+}
+
+func runValidate(args []string) int {
+	fs := flag.NewFlagSet("validate", flag.ContinueOnError)
+	schemaFile := fs.String("schema", "", "also validate against this JSON Schema")
+	asJSON := fs.Bool("json", false, "print results as JSON")
+	quiet := fs.Bool("q", false, "only print invalid documents")
+	pool := addPoolFlags(fs)
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return exitError
+	}
+
+	var schema *parser.Schema
+	if *schemaFile != "" {
+		content, err := readInput(*schemaFile)
+		if err != nil {
+			errorf("%v", err)
+			return exitError
+		}
+		schema, err = parser.CompileSchema(content)
+		if err != nil {
+			errorf("%s: %v", *schemaFile, err)
+			return exitError
+		}
+	}
+
+	files, err := expandInputs(args)
+	if err != nil {
+		errorf("%v", err)
+		return exitError
+	}
+
+	results := make([]validateResult, 0, len(files))
+	sum := newSummary("ok", "invalid", "error")
+	code := exitOK
+
+	validate := func(file string) validateResult {
+		return validateFile(file, schema)
+	}
+	forEachFile(files, *pool.jobs, *pool.unordered, validate, func(res validateResult) {
+		sum.add(res.outcome())
+		switch res.outcome() {
+		case "error":
+			code = exitError
+		case "invalid":
+			code = max(code, exitFailure)
+		}
+
+		if *asJSON {
+			results = append(results, res)
+			return
+		}
+
+		switch {
+		case res.Valid && !*quiet:
+			fmt.Printf("%s: ok\n", res.File)
+		case res.Error != "":
+			fmt.Printf("%s: %s\n", res.File, res.Error)
+		}
+		for _, v := range res.Violations {
+			fmt.Printf("%s:%v: %s (instance %q, schema %q)\n", res.File, v.Pos, v.Message, v.InstancePath, v.SchemaPath)
+		}
+	})
+
+	if *asJSON {
+		writeJSON(results)
+	}
+	if *pool.summary {
+		sum.write(os.Stderr, *pool.jobs)
+	}
+	return code
+}
+
+func validateFile(file string, schema *parser.Schema) validateResult {
+	res := validateResult{File: file}
+
+	content, err := readInput(file)
+	if err != nil {
+		res.Error = err.Error()
+		res.readFailed = true
+		return res
+	}
+
+	if schema == nil {
+		if err := parser.Validate(content); err != nil {
+			res.Error = err.Error()
+			return res
+		}
+		res.Valid = true
+		return res
+	}
+
+	violations, err := schema.Validate(content)
+	if err != nil {
+		res.Error = err.Error()
+		return res
+	}
+	res.Violations = violations
+	res.Valid = len(violations) == 0
+	return res
+}
@@ -0,0 +1,84 @@
+package parser
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// The JSONTestSuite corpus (https://github.com/nst/JSONTestSuite) names its
+// files by expectation: y_ must be accepted, n_ must be rejected and i_ is
+// left to the implementation.
+const conformanceDir = "testdata/JSONTestSuite/test_parsing"
+
+// parseDocument runs the lexer and parser the way callers do, turning
+// panics into errors so a crash shows up as a failure instead of aborting
+// the whole run
+func parseDocument(input []byte) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	p, err := NewParser(input)
+	if err != nil {
+		return err
+	}
+
+	_, err = p.Parse()
+	return err
+}
+
+func TestConformance(t *testing.T) {
+	files, err := filepath.Glob(filepath.Join(conformanceDir, "*.json"))
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no conformance files in %s: %v", conformanceDir, err)
+	}
+	sort.Strings(files)
+
+	type counts struct{ accepted, rejected, panicked int }
+	matrix := map[string]*counts{"y": {}, "n": {}, "i": {}}
+
+	for _, file := range files {
+		name := filepath.Base(file)
+		expect := name[:1]
+
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("error reading %s: %v", file, err)
+		}
+
+		err = parseDocument(content)
+
+		c := matrix[expect]
+		switch {
+		case err != nil && strings.HasPrefix(err.Error(), "panic:"):
+			c.panicked++
+		case err != nil:
+			c.rejected++
+		default:
+			c.accepted++
+		}
+
+		t.Run(strings.TrimSuffix(name, ".json"), func(t *testing.T) {
+			switch {
+			case err != nil && strings.HasPrefix(err.Error(), "panic:"):
+				t.Errorf("%v", err)
+			case expect == "y" && err != nil:
+				t.Errorf("valid document rejected: %v", err)
+			case expect == "n" && err == nil:
+				t.Errorf("invalid document accepted")
+			}
+		})
+	}
+
+	t.Logf("%-12s %9s %9s %9s", "expectation", "accepted", "rejected", "panicked")
+	for _, e := range []string{"y", "n", "i"} {
+		c := matrix[e]
+		t.Logf("%-12s %9d %9d %9d", e+"_", c.accepted, c.rejected, c.panicked)
+	}
+}
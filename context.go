@@ -0,0 +1,34 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+)
+
+// checkEvery is how many tokens or values are handled between two looks at
+// a context, so that checking it stays cheap next to the work itself
+const checkEvery = 1024
+
+// ContextError is returned when a document is abandoned because its
+// context was canceled or its deadline passed.
+type ContextError struct {
+	// Offset is the byte offset that had been reached
+	Offset int
+	Err    error
+}
+
+func (e *ContextError) Error() string {
+	return fmt.Sprintf("stopped at offset %d: %v", e.Offset, e.Err)
+}
+
+func (e *ContextError) Unwrap() error {
+	return e.Err
+}
+
+// checkContext returns a ContextError if ctx is done
+func checkContext(ctx context.Context, offset int) error {
+	if err := ctx.Err(); err != nil {
+		return &ContextError{Offset: offset, Err: err}
+	}
+	return nil
+}
@@ -0,0 +1,105 @@
+package parser
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+// contextSample is big enough for every variant to look at the context
+// before it is done
+func contextSample() []byte {
+	return []byte("[" + strings.Repeat(`{"a": [1, "x", null]}, `, 2000) + "true]")
+}
+
+func assertContextError(t *testing.T, name string, err error, want error) *ContextError {
+	t.Helper()
+
+	var ce *ContextError
+	if !errors.As(err, &ce) {
+		t.Fatalf("%s: expected a ContextError, got %v", name, err)
+	}
+	if !errors.Is(err, want) {
+		t.Errorf("%s: expected %v, got %v", name, want, err)
+	}
+	return ce
+}
+
+func TestContextCanceled(t *testing.T) {
+	input := contextSample()
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	_, err := NewLexerBytes(input).TokenizeContext(ctx)
+	ce := assertContextError(t, "TokenizeContext", err, context.Canceled)
+	if ce.Offset <= 0 || ce.Offset >= len(input) {
+		t.Errorf("expected the offset reached, got %d", ce.Offset)
+	}
+
+	_, err = NewParserContext(ctx, input)
+	assertContextError(t, "NewParserContext", err, context.Canceled)
+
+	p, err := NewParser(input)
+	if err != nil {
+		t.Fatalf("error creating parser %v", err)
+	}
+	_, err = p.ParseContext(ctx)
+	ce = assertContextError(t, "ParseContext", err, context.Canceled)
+	if ce.Offset <= 0 || ce.Offset >= len(input) {
+		t.Errorf("expected the offset reached, got %d", ce.Offset)
+	}
+
+	_, err = ParseValueContext(ctx, input)
+	assertContextError(t, "ParseValueContext", err, context.Canceled)
+
+	err = ValidReaderContext(ctx, bytes.NewReader(input))
+	assertContextError(t, "ValidReaderContext", err, context.Canceled)
+}
+
+func TestContextNotDone(t *testing.T) {
+	input := contextSample()
+
+	v, err := ParseValueContext(context.Background(), input)
+	if err != nil {
+		t.Fatalf("error parsing %v", err)
+	}
+	if len(v.Elems) != 2001 {
+		t.Errorf("expected 2001 elements, got %d", len(v.Elems))
+	}
+
+	if err := ValidReaderContext(context.Background(), bytes.NewReader(input)); err != nil {
+		t.Errorf("error validating %v", err)
+	}
+}
+
+// slowReader hands out its input a few bytes at a time with a pause before
+// every read
+type slowReader struct {
+	input []byte
+	pause time.Duration
+}
+
+func (r *slowReader) Read(p []byte) (int, error) {
+	time.Sleep(r.pause)
+	n := copy(p[:min(len(p), 16)], r.input)
+	r.input = r.input[n:]
+	if n == 0 {
+		return 0, errors.New("unexpected read past the end")
+	}
+	return n, nil
+}
+
+func TestContextDeadline(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	r := &slowReader{input: contextSample(), pause: time.Millisecond}
+	err := ValidReaderContext(ctx, r)
+	ce := assertContextError(t, "ValidReaderContext", err, context.DeadlineExceeded)
+	if ce.Offset <= 0 {
+		t.Errorf("expected the offset reached, got %d", ce.Offset)
+	}
+}
@@ -0,0 +1,390 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+)
+
+// CST is a concrete syntax tree of a document. Unlike a Value tree it
+// keeps everything the document was written with: whitespace, comments in
+// JSONC mode, key order, duplicate keys, number spellings and string
+// escapes. Bytes writes it back out byte for byte, so edits to one node
+// leave the rest of the document untouched.
+type CST struct {
+	Root *CSTNode
+	// After is the whitespace and comments after the top level value
+	After string
+}
+
+// CSTNode is a value in a CST. Positions are those of the parsed input and
+// are not updated when the tree is edited.
+type CSTNode struct {
+	Kind Kind
+	Pos  Position
+	// Before is the whitespace and comments in front of the value
+	Before string
+	// Text is the source of a scalar exactly as written, e.g. 1.50 or
+	// "café" with its quotes
+	Text string
+	// Items are the elements of an array or the members of an object
+	Items []*CSTItem
+	// End is the whitespace and comments in front of the closing bracket
+	// or brace
+	End string
+}
+
+// CSTItem is an element of an array or a member of an object.
+type CSTItem struct {
+	// KeyBefore is the whitespace and comments in front of the key
+	KeyBefore string
+	// Key is the key of a member as written, with its quotes, and empty
+	// for array elements
+	Key string
+	// ColonBefore is the whitespace and comments between key and colon
+	ColonBefore string
+	Value       *CSTNode
+	// Comma is set if a comma follows the item, CommaBefore holds what
+	// comes between the value and the comma
+	Comma       bool
+	CommaBefore string
+}
+
+// CSTOptions controls what ParseCST accepts.
+type CSTOptions struct {
+	// JSONC allows // and /* */ comments and a comma after the last
+	// element or member
+	JSONC bool
+}
+
+// ParseCST parses input into a lossless syntax tree.
+func ParseCST(input []byte, opts CSTOptions) (*CST, error) {
+	p := &cstParser{
+		lexer: Lexer{src: string(input), pos: Position{Line: 1, Col: 1}},
+		jsonc: opts.JSONC,
+	}
+
+	root, err := p.value()
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := p.trivia()
+	if err != nil {
+		return nil, err
+	}
+	t, err := p.lexer.nextBytes()
+	if err != nil {
+		return nil, err
+	}
+	if t.TokenType != EOF {
+		return nil, fmt.Errorf("unexpected %s after top level value at %v", describeToken(t), t.Pos)
+	}
+
+	return &CST{Root: root, After: after}, nil
+}
+
+type cstParser struct {
+	lexer Lexer
+	jsonc bool
+}
+
+// trivia consumes the whitespace and comments in front of the next token
+func (p *cstParser) trivia() (string, error) {
+	l := &p.lexer
+	src := l.src
+	start := l.pos.Offset
+
+	for i := start; i < len(src); {
+		switch c := src[i]; {
+		case c == '\n':
+			l.pos.Line++
+			l.pos.Col = 1
+			i++
+		case c == ' ' || c == '\t' || c == '\r':
+			l.pos.Col++
+			i++
+		case c == '/' && p.jsonc && strings.HasPrefix(src[i:], "//"):
+			n := strings.IndexByte(src[i:], '\n')
+			if n < 0 {
+				n = len(src) - i
+			}
+			l.pos.Col += n
+			i += n
+		case c == '/' && p.jsonc && strings.HasPrefix(src[i:], "/*"):
+			n := strings.Index(src[i+2:], "*/")
+			if n < 0 {
+				return "", fmt.Errorf("unterminated comment at %v", l.pos)
+			}
+			comment := src[i : i+n+4]
+			if lines := strings.Count(comment, "\n"); lines > 0 {
+				l.pos.Line += lines
+				l.pos.Col = len(comment) - strings.LastIndexByte(comment, '\n')
+			} else {
+				l.pos.Col += len(comment)
+			}
+			i += len(comment)
+		default:
+			l.pos.Offset = i
+			return src[start:i], nil
+		}
+		l.pos.Offset = i
+	}
+	return src[start:], nil
+}
+
+// next reads the trivia and the token after it
+func (p *cstParser) next() (string, Token, error) {
+	before, err := p.trivia()
+	if err != nil {
+		return "", Token{}, err
+	}
+	t, err := p.lexer.nextBytes()
+	return before, t, err
+}
+
+// text returns the source of token t, which was just read
+func (p *cstParser) text(t Token) string {
+	return p.lexer.src[t.Pos.Offset:p.lexer.pos.Offset]
+}
+
+// value parses a value with the trivia in front of it
+func (p *cstParser) value() (*CSTNode, error) {
+	before, t, err := p.next()
+	if err != nil {
+		return nil, err
+	}
+
+	n := &CSTNode{Pos: t.Pos, Before: before}
+	switch t.TokenType {
+	case NULL:
+		n.Kind = NULL_VALUE
+	case TRUE, FALSE:
+		n.Kind = BOOL_VALUE
+	case NUMBER:
+		n.Kind = NUMBER_VALUE
+	case STRING:
+		n.Kind = STRING_VALUE
+	case LEFT_BRACKET:
+		n.Kind = ARRAY_VALUE
+		return n, p.items(n, RIGHT_BRACKET)
+	case LEFT_BRACE:
+		n.Kind = OBJECT_VALUE
+		return n, p.items(n, RIGHT_BRACE)
+	default:
+		return nil, fmt.Errorf("expected value at %v, got %s", t.Pos, describeToken(t))
+	}
+
+	n.Text = p.text(t)
+	return n, nil
+}
+
+// items parses the elements or members of n up to the closing token
+func (p *cstParser) items(n *CSTNode, close TokenType) error {
+	for {
+		// look ahead for the closing token, a key or the start of a value
+		mark := p.lexer.pos
+		before, t, err := p.next()
+		if err != nil {
+			return err
+		}
+
+		if t.TokenType == close {
+			if k := len(n.Items); k > 0 && n.Items[k-1].Comma && !p.jsonc {
+				return fmt.Errorf("extra comma before %s at %v", describeToken(t), t.Pos)
+			}
+			n.End = before
+			return nil
+		}
+		if k := len(n.Items); k > 0 && !n.Items[k-1].Comma {
+			return fmt.Errorf("expected comma or closing bracket at %v, got %s", t.Pos, describeToken(t))
+		}
+
+		item := &CSTItem{}
+		if n.Kind == OBJECT_VALUE {
+			if t.TokenType != STRING {
+				return fmt.Errorf("expected object key at %v, got %s", t.Pos, describeToken(t))
+			}
+			item.KeyBefore, item.Key = before, p.text(t)
+
+			if item.ColonBefore, t, err = p.next(); err != nil {
+				return err
+			}
+			if t.TokenType != COLON {
+				return fmt.Errorf("expected colon at %v, got %s", t.Pos, describeToken(t))
+			}
+		} else {
+			// the token starts the element, read it again with its trivia
+			p.lexer.pos = mark
+		}
+
+		if item.Value, err = p.value(); err != nil {
+			return err
+		}
+		n.Items = append(n.Items, item)
+
+		mark = p.lexer.pos
+		before, t, err = p.next()
+		if err != nil {
+			return err
+		}
+		if t.TokenType == COMMA {
+			item.Comma, item.CommaBefore = true, before
+		} else {
+			p.lexer.pos = mark
+		}
+	}
+}
+
+// Bytes returns the document the tree describes.
+func (c *CST) Bytes() []byte {
+	return []byte(c.String())
+}
+
+func (c *CST) String() string {
+	var sb strings.Builder
+	c.Root.write(&sb)
+	sb.WriteString(c.After)
+	return sb.String()
+}
+
+// String returns the source of the value with the trivia in front of it.
+func (n *CSTNode) String() string {
+	var sb strings.Builder
+	n.write(&sb)
+	return sb.String()
+}
+
+func (n *CSTNode) write(sb *strings.Builder) {
+	sb.WriteString(n.Before)
+
+	open, close := "[", "]"
+	switch n.Kind {
+	case OBJECT_VALUE:
+		open, close = "{", "}"
+	case ARRAY_VALUE:
+	default:
+		sb.WriteString(n.Text)
+		return
+	}
+
+	sb.WriteString(open)
+	for _, it := range n.Items {
+		if it.Key != "" {
+			sb.WriteString(it.KeyBefore)
+			sb.WriteString(it.Key)
+			sb.WriteString(it.ColonBefore)
+			sb.WriteByte(':')
+		}
+		it.Value.write(sb)
+		if it.Comma {
+			sb.WriteString(it.CommaBefore)
+			sb.WriteByte(',')
+		}
+	}
+	sb.WriteString(n.End)
+	sb.WriteString(close)
+}
+
+// Name returns the decoded key of an object member.
+func (it *CSTItem) Name() (string, error) {
+	if len(it.Key) < 2 {
+		return "", fmt.Errorf("item has no key")
+	}
+	// strip the quotes
+	return unquote(it.Key[1 : len(it.Key)-1])
+}
+
+// Get returns the value of the last member named key, or nil if n is not
+// an object or has no such member.
+func (n *CSTNode) Get(key string) *CSTNode {
+	if i := n.member(key); i >= 0 {
+		return n.Items[i].Value
+	}
+	return nil
+}
+
+// member returns the index of the last member named key, or -1
+func (n *CSTNode) member(key string) int {
+	if n == nil || n.Kind != OBJECT_VALUE {
+		return -1
+	}
+	for i := len(n.Items) - 1; i >= 0; i-- {
+		if name, err := n.Items[i].Name(); err == nil && name == key {
+			return i
+		}
+	}
+	return -1
+}
+
+// Index returns the i-th element of an array, or nil if n is not an array
+// or i is out of range.
+func (n *CSTNode) Index(i int) *CSTNode {
+	if n == nil || n.Kind != ARRAY_VALUE || i < 0 || i >= len(n.Items) {
+		return nil
+	}
+	return n.Items[i].Value
+}
+
+// Lookup resolves a JSON Pointer against n. It returns nil if the pointer
+// is malformed or does not refer to an existing value.
+func (n *CSTNode) Lookup(ptr string) *CSTNode {
+	if ptr == "" {
+		return n
+	}
+	if !strings.HasPrefix(ptr, "/") {
+		return nil
+	}
+
+	cur := n
+	for _, tok := range strings.Split(ptr[1:], "/") {
+		tok = pointerUnescaper.Replace(tok)
+		switch cur.Kind {
+		case OBJECT_VALUE:
+			cur = cur.Get(tok)
+		case ARRAY_VALUE:
+			i, ok := pointerIndex(tok, len(cur.Items))
+			if !ok {
+				return nil
+			}
+			cur = cur.Index(i)
+		default:
+			return nil
+		}
+		if cur == nil {
+			return nil
+		}
+	}
+	return cur
+}
+
+// Value decodes n and everything below it, ignoring comments.
+func (n *CSTNode) Value() (*Value, error) {
+	var sb strings.Builder
+	n.writeValue(&sb)
+	return ParseValue([]byte(sb.String()))
+}
+
+// writeValue writes n without trivia and trailing commas
+func (n *CSTNode) writeValue(sb *strings.Builder) {
+	switch n.Kind {
+	case ARRAY_VALUE, OBJECT_VALUE:
+		open, close := byte('['), byte(']')
+		if n.Kind == OBJECT_VALUE {
+			open, close = '{', '}'
+		}
+		sb.WriteByte(open)
+		for i, it := range n.Items {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			if it.Key != "" {
+				sb.WriteString(it.Key)
+				sb.WriteByte(':')
+			}
+			it.Value.writeValue(sb)
+		}
+		sb.WriteByte(close)
+	default:
+		sb.WriteString(n.Text)
+	}
+}
@@ -0,0 +1,121 @@
+package parser
+
+import (
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const jsoncSample = `// settings
+{
+  /* editor */ "tabSize" : 4,
+  "ratio": 1.50e+0,   // kept as written
+  "name": "café \/ bar",
+  "list": [ 1, 2 ,3, ],
+  "empty": {  },
+}
+`
+
+func TestCSTRoundTrip(t *testing.T) {
+	files, _ := filepath.Glob(filepath.Join(conformanceDir, "*.json"))
+	fixtures, _ := filepath.Glob("./main/test*/*.json")
+
+	for _, file := range append(files, fixtures...) {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("error reading %s: %v", file, err)
+		}
+
+		cst, err := ParseCST(content, CSTOptions{})
+		if (err == nil) != Valid(content) {
+			t.Errorf("%s: ParseCST returned %v, Valid %v", file, err, Valid(content))
+			continue
+		}
+		if err == nil && string(cst.Bytes()) != string(content) {
+			t.Errorf("%s: round trip changed the document", file)
+		}
+	}
+}
+
+func TestCSTRoundTripGenerated(t *testing.T) {
+	rng := rand.New(rand.NewPCG(3, 4))
+
+	for i := 0; i < 500; i++ {
+		var sb strings.Builder
+		genValue(rng, &sb, 0)
+		doc := []byte(sb.String())
+		if i%2 == 1 {
+			doc = mutate(rng, doc)
+		}
+
+		cst, err := ParseCST(doc, CSTOptions{})
+		if (err == nil) != Valid(doc) {
+			t.Errorf("%q: ParseCST returned %v, Valid %v", doc, err, Valid(doc))
+			continue
+		}
+		if err == nil && string(cst.Bytes()) != string(doc) {
+			t.Errorf("%q: round trip returned %q", doc, cst.Bytes())
+		}
+	}
+}
+
+func TestCSTJSONC(t *testing.T) {
+	cst, err := ParseCST([]byte(jsoncSample), CSTOptions{JSONC: true})
+	if err != nil {
+		t.Fatalf("error parsing %v", err)
+	}
+	if got := string(cst.Bytes()); got != jsoncSample {
+		t.Errorf("round trip changed the document:\n%s", got)
+	}
+
+	if n := cst.Root.Lookup("/ratio"); n == nil || n.Text != "1.50e+0" {
+		t.Errorf("expected the number as written, got %v", n)
+	}
+	if n := cst.Root.Lookup("/name"); n == nil || n.Text != `"café \/ bar"` {
+		t.Errorf("expected the string as written, got %v", n)
+	}
+	if n := cst.Root.Lookup("/list/2"); n == nil || n.Text != "3" || n.Pos != (Position{Offset: 129, Line: 6, Col: 19}) {
+		t.Errorf("unexpected element %+v", n)
+	}
+
+	item := cst.Root.Items[0]
+	if item.KeyBefore != "\n  /* editor */ " || item.ColonBefore != " " {
+		t.Errorf("unexpected trivia %q %q", item.KeyBefore, item.ColonBefore)
+	}
+	if name, _ := item.Name(); name != "tabSize" {
+		t.Errorf("expected tabSize, got %q", name)
+	}
+
+	v, err := cst.Root.Value()
+	if err != nil {
+		t.Fatalf("error decoding %v", err)
+	}
+	if v.Get("name").Str != "café / bar" || len(v.Get("list").Elems) != 3 {
+		t.Errorf("unexpected value %v", v)
+	}
+}
+
+func TestCSTErrors(t *testing.T) {
+	for _, c := range []struct {
+		input string
+		jsonc bool
+	}{
+		{`// comment` + "\n" + `{}`, false},
+		{`[1, 2,]`, false},
+		{`{"a": 1,}`, false},
+		{`[1 /* open`, true},
+		{`[1 2]`, true},
+		{`{"a" 1}`, true},
+		{`{1: 2}`, true},
+		{`[,]`, true},
+		{`[1]]`, true},
+		{``, true},
+		{`/* only */`, true},
+	} {
+		if _, err := ParseCST([]byte(c.input), CSTOptions{JSONC: c.jsonc}); err == nil {
+			t.Errorf("%q: error should have been raised", c.input)
+		}
+	}
+}
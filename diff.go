@@ -0,0 +1,311 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"strings"
+)
+
+type ChangeType int
+
+const (
+	ADDED ChangeType = iota
+	REMOVED
+	CHANGED
+)
+
+func (c ChangeType) String() string {
+	switch c {
+	case ADDED:
+		return "added"
+	case REMOVED:
+		return "removed"
+	case CHANGED:
+		return "changed"
+	}
+	return "unknown"
+}
+
+// Change is a single difference between two documents. From is nil for
+// added values and To is nil for removed ones; both carry the position the
+// value was read from.
+type Change struct {
+	Type ChangeType
+	Path string
+	From *Value
+	To   *Value
+}
+
+type DiffOptions struct {
+	// IgnoreArrayOrder compares arrays as multisets instead of by index.
+	IgnoreArrayOrder bool
+	// IgnorePaths lists JSON Pointers whose values, including everything
+	// below them, are not compared.
+	IgnorePaths []string
+	// NumericTolerance is the largest absolute difference at which two
+	// numbers are still considered equal.
+	NumericTolerance float64
+}
+
+// Diff reports the differences between documents a and b.
+func Diff(a, b []byte) ([]Change, error) {
+	return DiffWithOptions(a, b, DiffOptions{})
+}
+
+func DiffWithOptions(a, b []byte, opts DiffOptions) ([]Change, error) {
+	va, err := ParseValue(a)
+	if err != nil {
+		return nil, fmt.Errorf("first document: %v", err)
+	}
+
+	vb, err := ParseValue(b)
+	if err != nil {
+		return nil, fmt.Errorf("second document: %v", err)
+	}
+
+	return DiffValues(va, vb, opts), nil
+}
+
+func DiffValues(a, b *Value, opts DiffOptions) []Change {
+	d := differ{opts: opts, changes: []Change{}}
+	d.diff("", a, b)
+	return d.changes
+}
+
+// Equal reports whether a and b hold the same JSON value. Numbers are
+// compared by value and object member order is not significant.
+func Equal(a, b *Value) bool {
+	return equal(a, b, DiffOptions{})
+}
+
+type differ struct {
+	opts    DiffOptions
+	changes []Change
+}
+
+func (d *differ) ignored(path string) bool {
+	for _, p := range d.opts.IgnorePaths {
+		if path == p || strings.HasPrefix(path, p+"/") {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *differ) add(t ChangeType, path string, from, to *Value) {
+	if d.ignored(path) {
+		return
+	}
+	d.changes = append(d.changes, Change{Type: t, Path: path, From: from, To: to})
+}
+
+func (d *differ) diff(path string, a, b *Value) {
+	if d.ignored(path) {
+		return
+	}
+
+	if a.Kind != b.Kind {
+		d.add(CHANGED, path, a, b)
+		return
+	}
+
+	switch a.Kind {
+	case OBJECT_VALUE:
+		d.diffObject(path, a, b)
+	case ARRAY_VALUE:
+		if d.opts.IgnoreArrayOrder {
+			d.diffUnordered(path, a, b)
+		} else {
+			d.diffArray(path, a, b)
+		}
+	default:
+		if !equal(a, b, d.opts) {
+			d.add(CHANGED, path, a, b)
+		}
+	}
+}
+
+func (d *differ) diffObject(path string, a, b *Value) {
+	la, lb := lastMembers(a), lastMembers(b)
+
+	for _, m := range uniqueMembersOf(a, la) {
+		p := pointerAppendKey(path, m.Key)
+		if other := lb[m.Key]; other != nil {
+			d.diff(p, m.Value, other)
+		} else {
+			d.add(REMOVED, p, m.Value, nil)
+		}
+	}
+
+	for _, m := range uniqueMembersOf(b, lb) {
+		if la[m.Key] == nil {
+			d.add(ADDED, pointerAppendKey(path, m.Key), nil, m.Value)
+		}
+	}
+}
+
+func (d *differ) diffArray(path string, a, b *Value) {
+	for i := 0; i < len(a.Elems) || i < len(b.Elems); i++ {
+		p := pointerAppendIndex(path, i)
+		switch {
+		case i >= len(b.Elems):
+			d.add(REMOVED, p, a.Elems[i], nil)
+		case i >= len(a.Elems):
+			d.add(ADDED, p, nil, b.Elems[i])
+		default:
+			d.diff(p, a.Elems[i], b.Elems[i])
+		}
+	}
+}
+
+// diffUnordered pairs up equal elements regardless of their index and
+// reports whatever is left on either side
+func (d *differ) diffUnordered(path string, a, b *Value) {
+	matched := make([]bool, len(b.Elems))
+
+	for i, ea := range a.Elems {
+		found := false
+		for j, eb := range b.Elems {
+			if !matched[j] && equal(ea, eb, d.opts) {
+				matched[j] = true
+				found = true
+				break
+			}
+		}
+		if !found {
+			d.add(REMOVED, pointerAppendIndex(path, i), ea, nil)
+		}
+	}
+
+	for j, eb := range b.Elems {
+		if !matched[j] {
+			d.add(ADDED, pointerAppendIndex(path, j), nil, eb)
+		}
+	}
+}
+
+// uniqueMembers drops all but the last occurrence of duplicated keys, which
+// is the one Get returns
+func uniqueMembers(v *Value) []Member {
+	return uniqueMembersOf(v, lastMembers(v))
+}
+
+// uniqueMembersOf is uniqueMembers with the map lastMembers returns for v
+func uniqueMembersOf(v *Value, last map[string]*Value) []Member {
+	out := make([]Member, 0, len(last))
+	for _, m := range v.Members {
+		if last[m.Key] == m.Value {
+			out = append(out, m)
+		}
+	}
+	return out
+}
+
+// lastMembers maps every key of an object to its last value, the one Get
+// returns, so that wide objects are not searched member by member
+func lastMembers(v *Value) map[string]*Value {
+	last := make(map[string]*Value, len(v.Members))
+	for _, m := range v.Members {
+		last[m.Key] = m.Value
+	}
+	return last
+}
+
+func equal(a, b *Value, opts DiffOptions) bool {
+	if a.Kind != b.Kind {
+		return false
+	}
+
+	switch a.Kind {
+	case NULL_VALUE:
+		return true
+	case BOOL_VALUE:
+		return a.Bool == b.Bool
+	case NUMBER_VALUE:
+		if a.Number == b.Number {
+			return true
+		}
+		if opts.NumericTolerance > 0 {
+			return math.Abs(a.Float()-b.Float()) <= opts.NumericTolerance
+		}
+		// exact, so that large integers like IDs are told apart
+		ra, okA := numberRat(a)
+		rb, okB := numberRat(b)
+		return okA && okB && ra.Cmp(rb) == 0
+	case STRING_VALUE:
+		return a.Str == b.Str
+	case ARRAY_VALUE:
+		if len(a.Elems) != len(b.Elems) {
+			return false
+		}
+		if opts.IgnoreArrayOrder {
+			d := differ{opts: opts}
+			d.diffUnordered("", a, b)
+			return len(d.changes) == 0
+		}
+		for i := range a.Elems {
+			if !equal(a.Elems[i], b.Elems[i], opts) {
+				return false
+			}
+		}
+		return true
+	case OBJECT_VALUE:
+		la, lb := lastMembers(a), lastMembers(b)
+		if len(la) != len(lb) {
+			return false
+		}
+		for key, va := range la {
+			other := lb[key]
+			if other == nil || !equal(va, other, opts) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+const (
+	colorRed    = "\x1b[31m"
+	colorGreen  = "\x1b[32m"
+	colorYellow = "\x1b[33m"
+	colorReset  = "\x1b[0m"
+)
+
+// WriteDiff renders changes in a human readable form, one change per block,
+// citing the line and column of each value in the first (a) and second (b)
+// document. With color set the output uses ANSI escape codes.
+func WriteDiff(w io.Writer, changes []Change, color bool) error {
+	paint := func(c, s string) string {
+		if !color {
+			return s
+		}
+		return c + s + colorReset
+	}
+
+	for _, c := range changes {
+		path := c.Path
+		if path == "" {
+			path = "(root)"
+		}
+
+		var err error
+		switch c.Type {
+		case ADDED:
+			_, err = fmt.Fprintf(w, "%s %s (b %v)\n  %s\n",
+				paint(colorGreen, "+"), path, c.To.Pos, paint(colorGreen, "+ "+c.To.String()))
+		case REMOVED:
+			_, err = fmt.Fprintf(w, "%s %s (a %v)\n  %s\n",
+				paint(colorRed, "-"), path, c.From.Pos, paint(colorRed, "- "+c.From.String()))
+		case CHANGED:
+			_, err = fmt.Fprintf(w, "%s %s (a %v, b %v)\n  %s\n  %s\n",
+				paint(colorYellow, "~"), path, c.From.Pos, c.To.Pos,
+				paint(colorRed, "- "+c.From.String()), paint(colorGreen, "+ "+c.To.String()))
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,175 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestDiffObjects(t *testing.T) {
+	a := []byte("{\"name\": \"a\", \"keep\": 1, \"gone\": true}")
+	b := []byte("{\"name\": \"b\", \"keep\": 1.0, \"new\": null}")
+
+	changes, err := Diff(a, b)
+	if err != nil {
+		t.Fatalf("error diffing %v", err)
+	}
+
+	want := []struct {
+		Type ChangeType
+		Path string
+	}{{CHANGED, "/name"}, {REMOVED, "/gone"}, {ADDED, "/new"}}
+
+	if len(changes) != len(want) {
+		t.Fatalf("expected %d changes, got %v", len(want), changes)
+	}
+
+	for i, c := range changes {
+		if c.Type != want[i].Type || c.Path != want[i].Path {
+			t.Errorf("expected %v %s, got %v %s", want[i].Type, want[i].Path, c.Type, c.Path)
+		}
+	}
+}
+
+func TestDiffArrays(t *testing.T) {
+	a := []byte("[1, 2, 3]")
+	b := []byte("[3, 2, 1, 4]")
+
+	changes, err := Diff(a, b)
+	if err != nil {
+		t.Fatalf("error diffing %v", err)
+	}
+
+	if len(changes) != 3 || changes[2].Type != ADDED || changes[2].Path != "/3" {
+		t.Errorf("unexpected changes %v", changes)
+	}
+
+	changes, err = DiffWithOptions(a, b, DiffOptions{IgnoreArrayOrder: true})
+	if err != nil {
+		t.Fatalf("error diffing %v", err)
+	}
+
+	if len(changes) != 1 || changes[0].Type != ADDED || changes[0].To.String() != "4" {
+		t.Errorf("unexpected changes %v", changes)
+	}
+}
+
+func TestDiffOptions(t *testing.T) {
+	a := []byte("{\"price\": 10.001, \"meta\": {\"updated\": \"monday\"}, \"id\": 1}")
+	b := []byte("{\"price\": 10.0, \"meta\": {\"updated\": \"tuesday\"}, \"id\": 2}")
+
+	changes, err := DiffWithOptions(a, b, DiffOptions{
+		IgnorePaths:      []string{"/meta"},
+		NumericTolerance: 0.01,
+	})
+	if err != nil {
+		t.Fatalf("error diffing %v", err)
+	}
+
+	if len(changes) != 1 || changes[0].Path != "/id" {
+		t.Errorf("unexpected changes %v", changes)
+	}
+}
+
+func TestDiffLargeIntegers(t *testing.T) {
+	// both round to the same float64
+	changes, err := Diff([]byte(`{"id": 9007199254740993}`), []byte(`{"id": 9007199254740992}`))
+	if err != nil {
+		t.Fatalf("error diffing %v", err)
+	}
+	if len(changes) != 1 || changes[0].Path != "/id" {
+		t.Errorf("unexpected changes %v", changes)
+	}
+
+	// different spellings of the same number are still equal
+	changes, err = Diff([]byte(`[1.0, 1e2, 12345678901234567890]`), []byte(`[1, 100, 12345678901234567890.0]`))
+	if err != nil {
+		t.Fatalf("error diffing %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("unexpected changes %v", changes)
+	}
+}
+
+func TestDiffWideObjects(t *testing.T) {
+	wide := func(changed int, extra string) []byte {
+		var sb strings.Builder
+		sb.WriteString(`{"k0": "dup"`)
+		for i := 0; i < 20000; i++ {
+			v := i
+			if i == changed {
+				v = -1
+			}
+			fmt.Fprintf(&sb, `, "k%d": %d`, i, v)
+		}
+		sb.WriteString(extra + "}")
+		return []byte(sb.String())
+	}
+
+	// the first k0 is a duplicate, only the last one counts
+	changes, err := Diff(wide(-1, ""), wide(123, `, "new": 1`))
+	if err != nil {
+		t.Fatalf("error diffing %v", err)
+	}
+	if len(changes) != 2 || changes[0].Path != "/k123" || changes[1].Path != "/new" || changes[1].Type != ADDED {
+		t.Errorf("unexpected changes %v", changes)
+	}
+
+	// unordered arrays compare their elements as a whole
+	arr := func(elems ...[]byte) []byte {
+		return []byte("[" + string(bytes.Join(elems, []byte(","))) + "]")
+	}
+	changes, err = DiffWithOptions(arr(wide(-1, ""), wide(7, "")), arr(wide(7, ""), wide(-1, "")), DiffOptions{IgnoreArrayOrder: true})
+	if err != nil {
+		t.Fatalf("error diffing %v", err)
+	}
+	if len(changes) != 0 {
+		t.Errorf("unexpected changes %v", changes)
+	}
+}
+
+func TestDiffPathEscaping(t *testing.T) {
+	changes, err := Diff([]byte("{\"a/b\": {\"c~d\": 1}}"), []byte("{\"a/b\": {\"c~d\": 2}}"))
+	if err != nil {
+		t.Fatalf("error diffing %v", err)
+	}
+
+	if len(changes) != 1 || changes[0].Path != "/a~1b/c~0d" {
+		t.Errorf("unexpected changes %v", changes)
+	}
+}
+
+func TestDiffInvalid(t *testing.T) {
+	_, err := Diff([]byte("{}"), []byte("{\"a\":}"))
+
+	if err == nil {
+		t.Errorf("error should have been raised")
+	}
+}
+
+func TestWriteDiff(t *testing.T) {
+	a := []byte("{\n  \"a\": 1,\n  \"b\": 2\n}")
+	b := []byte("{\n  \"b\": 3\n}")
+
+	changes, err := Diff(a, b)
+	if err != nil {
+		t.Fatalf("error diffing %v", err)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteDiff(&buf, changes, false); err != nil {
+		t.Fatalf("error writing diff %v", err)
+	}
+
+	want := "- /a (a 2:8)\n  - 1\n~ /b (a 3:8, b 2:8)\n  - 2\n  + 3\n"
+	if buf.String() != want {
+		t.Errorf("expected %q, got %q", want, buf.String())
+	}
+
+	buf.Reset()
+	WriteDiff(&buf, changes, true)
+	if !strings.Contains(buf.String(), colorRed) {
+		t.Errorf("expected colored output, got %q", buf.String())
+	}
+}
@@ -0,0 +1,247 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// differentialDocs is the number of generated documents, half of them
+// mutated so that most are invalid
+const differentialDocs = 5000
+
+// decodeValue parses input with this package into the same shape
+// encoding/json produces with UseNumber, recovering panics like
+// parseDocument does
+func decodeValue(input []byte) (out any, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	v, err := ParseValue(input)
+	if err != nil {
+		return nil, err
+	}
+	return toAny(v), nil
+}
+
+func toAny(v *Value) any {
+	switch v.Kind {
+	case BOOL_VALUE:
+		return v.Bool
+	case NUMBER_VALUE:
+		return json.Number(v.Number)
+	case STRING_VALUE:
+		return v.Str
+	case ARRAY_VALUE:
+		out := make([]any, 0, len(v.Elems))
+		for _, e := range v.Elems {
+			out = append(out, toAny(e))
+		}
+		return out
+	case OBJECT_VALUE:
+		// encoding/json keeps the last of duplicate keys as well
+		out := make(map[string]any, len(v.Members))
+		for _, m := range v.Members {
+			out[m.Key] = toAny(m.Value)
+		}
+		return out
+	}
+	return nil
+}
+
+func decodeStd(input []byte) (any, error) {
+	d := json.NewDecoder(bytes.NewReader(input))
+	d.UseNumber()
+
+	var out any
+	if err := d.Decode(&out); err != nil {
+		return nil, err
+	}
+	// Decode stops after the first value, Valid also rejects trailing data
+	if !json.Valid(input) {
+		return nil, fmt.Errorf("invalid trailing data")
+	}
+	return out, nil
+}
+
+// divergence describes how the two parsers disagree on input, empty if
+// they do not
+func divergence(input []byte) string {
+	ours, ourErr := decodeValue(input)
+	std, stdErr := decodeStd(input)
+
+	switch {
+	case ourErr != nil && strings.HasPrefix(ourErr.Error(), "panic:"):
+		return ourErr.Error()
+	case ourErr == nil && stdErr != nil:
+		return fmt.Sprintf("accepted, encoding/json rejects: %v", stdErr)
+	case ourErr != nil && stdErr == nil:
+		return fmt.Sprintf("rejected (%v), encoding/json accepts", ourErr)
+	case ourErr == nil && !reflect.DeepEqual(ours, std):
+		return fmt.Sprintf("decoded %#v, encoding/json decoded %#v", ours, std)
+	}
+	return ""
+}
+
+func differentialCorpus(t *testing.T) map[string][]byte {
+	files, _ := filepath.Glob(filepath.Join(conformanceDir, "*.json"))
+	fixtures, _ := filepath.Glob("./main/test*/*.json")
+	files = append(files, fixtures...)
+	if len(files) == 0 {
+		t.Fatalf("no corpus files found")
+	}
+
+	docs := map[string][]byte{}
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("error reading %s: %v", file, err)
+		}
+		docs[file] = content
+	}
+
+	// a fixed seed keeps failures reproducible
+	rng := rand.New(rand.NewPCG(1, 2))
+	for i := 0; i < differentialDocs; i++ {
+		var sb strings.Builder
+		genValue(rng, &sb, 0)
+		doc := []byte(sb.String())
+		if i%2 == 1 {
+			doc = mutate(rng, doc)
+		}
+		docs[fmt.Sprintf("generated/%d", i)] = doc
+	}
+
+	return docs
+}
+
+func TestDifferential(t *testing.T) {
+	docs := differentialCorpus(t)
+
+	names := make([]string, 0, len(docs))
+	for name := range docs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	accepted, diverged := 0, 0
+	for _, name := range names {
+		d := divergence(docs[name])
+		if d != "" {
+			diverged++
+			t.Errorf("%s: %q: %s", name, truncate(docs[name], 80), d)
+		}
+		if json.Valid(docs[name]) {
+			accepted++
+		}
+	}
+
+	t.Logf("%d documents, %d valid, %d divergences", len(docs), accepted, diverged)
+}
+
+func truncate(b []byte, n int) string {
+	if len(b) > n {
+		return string(b[:n]) + "..."
+	}
+	return string(b)
+}
+
+var (
+	genWhitespace = []string{"", "", "", " ", "\n", "\t", "\r\n  "}
+	genStrings    = []string{
+		"", "a", "key", "with space", `\"`, `\\`, `\/`, `\b\f\n\r\t`, `A`, `é`,
+		`😀`, `\ud800`, `\udc00x`, "é", "日本", "\x7f", "\xff", "\xc3", "😀",
+	}
+	genNumbers = []string{
+		"0", "-0", "1", "-1", "42", "1.5", "-0.25", "1e10", "1E-5", "2.5e+3", "123456789012345678901234567890",
+		"1e400", "0.000001",
+	}
+)
+
+// genValue writes a random valid JSON value
+func genValue(rng *rand.Rand, sb *strings.Builder, depth int) {
+	ws := func() { sb.WriteString(genWhitespace[rng.IntN(len(genWhitespace))]) }
+
+	kind := rng.IntN(7)
+	if depth > 4 && kind >= 5 {
+		kind = rng.IntN(5)
+	}
+
+	ws()
+	switch kind {
+	case 0:
+		sb.WriteString("null")
+	case 1:
+		sb.WriteString([]string{"true", "false"}[rng.IntN(2)])
+	case 2:
+		sb.WriteString(genNumbers[rng.IntN(len(genNumbers))])
+	case 3, 4:
+		genString(rng, sb)
+	case 5:
+		sb.WriteByte('[')
+		n := rng.IntN(4)
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			genValue(rng, sb, depth+1)
+		}
+		ws()
+		sb.WriteByte(']')
+	case 6:
+		sb.WriteByte('{')
+		n := rng.IntN(4)
+		for i := 0; i < n; i++ {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			ws()
+			genString(rng, sb)
+			ws()
+			sb.WriteByte(':')
+			genValue(rng, sb, depth+1)
+		}
+		ws()
+		sb.WriteByte('}')
+	}
+	ws()
+}
+
+func genString(rng *rand.Rand, sb *strings.Builder) {
+	sb.WriteByte('"')
+	n := rng.IntN(3)
+	for i := 0; i < n; i++ {
+		sb.WriteString(genStrings[rng.IntN(len(genStrings))])
+	}
+	sb.WriteByte('"')
+}
+
+// mutate inserts, deletes or replaces a single byte of doc with one that is
+// likely to matter to a JSON parser
+func mutate(rng *rand.Rand, doc []byte) []byte {
+	const interesting = "{}[],:\"\\ -+.eE0a\x00\n"
+
+	out := append([]byte{}, doc...)
+	at := rng.IntN(len(out) + 1)
+	c := interesting[rng.IntN(len(interesting))]
+
+	switch op := rng.IntN(3); {
+	case op == 0 || at == len(out):
+		out = append(out[:at], append([]byte{c}, out[at:]...)...)
+	case op == 1:
+		out = append(out[:at], out[at+1:]...)
+	default:
+		out[at] = c
+	}
+	return out
+}
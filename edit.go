@@ -0,0 +1,344 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// The edit methods change a CST in place. Values are given as JSON text.
+// New items copy the layout of their siblings: an item added to a
+// multi-line object goes on its own line with the same indentation, and an
+// object or array added there is laid out one item per line. A comment on
+// the same line as an item stays with that item.
+
+// SetAt replaces the value at the JSON Pointer ptr with value. Whatever is
+// in front of the old value, comments included, stays. If ptr names a
+// missing member of an existing object, the member is added at the end.
+func (c *CST) SetAt(ptr string, value []byte) error {
+	if ptr == "" {
+		n, err := newCSTValue(value, strings.Contains(c.Root.text(), "\n"), "", c.unit())
+		if err != nil {
+			return err
+		}
+		n.Before = c.Root.Before
+		c.Root = n
+		return nil
+	}
+
+	parent, tok, err := c.parent(ptr)
+	if err != nil {
+		return err
+	}
+
+	i := parent.find(tok)
+	if i < 0 {
+		if parent.Kind != OBJECT_VALUE {
+			return fmt.Errorf("no element at %q", ptr)
+		}
+		return c.insert(parent, len(parent.Items), &CSTItem{Key: quote(tok)}, value)
+	}
+
+	it := parent.Items[i]
+	_, indent := lineStyle(it.leading())
+	n, err := newCSTValue(value, strings.Contains(it.Value.text(), "\n"), indent, c.unit())
+	if err != nil {
+		return err
+	}
+	n.Before = it.Value.Before
+	it.Value = n
+	return nil
+}
+
+// DeleteAt removes the member or element at ptr with the comments on the
+// lines in front of it.
+func (c *CST) DeleteAt(ptr string) error {
+	if ptr == "" {
+		return fmt.Errorf("cannot delete the top level value")
+	}
+
+	parent, tok, err := c.parent(ptr)
+	if err != nil {
+		return err
+	}
+	i := parent.find(tok)
+	if i < 0 {
+		return fmt.Errorf("no value at %q", ptr)
+	}
+
+	del := parent.Items[i]
+	head, _ := splitLine(del.leading())
+
+	if i == len(parent.Items)-1 {
+		if prev := i - 1; prev >= 0 && !del.Comma {
+			parent.Items[prev].Comma = false
+			parent.Items[prev].CommaBefore = ""
+		}
+		// the comment after the deleted item goes, the one after the
+		// item before it stays
+		_, rest := splitLine(parent.End)
+		parent.End = head + rest
+	} else {
+		next := parent.Items[i+1]
+		lead := next.leading()
+		if i == 0 && !strings.Contains(lead, "\n") {
+			// on a single line the next item moves up behind the bracket
+			next.setLeading(leadingSpace(del.leading()) + strings.TrimLeft(lead, " \t"))
+		} else {
+			_, rest := splitLine(lead)
+			next.setLeading(head + rest)
+		}
+	}
+
+	parent.Items = slices.Delete(parent.Items, i, i+1)
+	return nil
+}
+
+// InsertAt inserts value as the element at index of the array at ptr. An
+// index equal to the length of the array appends.
+func (c *CST) InsertAt(ptr string, index int, value []byte) error {
+	n := c.Root.Lookup(ptr)
+	if n == nil {
+		return fmt.Errorf("no value at %q", ptr)
+	}
+	if n.Kind != ARRAY_VALUE {
+		return fmt.Errorf("value at %q is not an array", ptr)
+	}
+	if index < 0 || index > len(n.Items) {
+		return fmt.Errorf("index %d out of range for array at %q", index, ptr)
+	}
+	return c.insert(n, index, &CSTItem{}, value)
+}
+
+// RenameKey renames the member at ptr to name, keeping its value and its
+// place in the object.
+func (c *CST) RenameKey(ptr, name string) error {
+	parent, tok, err := c.parent(ptr)
+	if err != nil {
+		return err
+	}
+	if parent.Kind != OBJECT_VALUE {
+		return fmt.Errorf("value at %q is not an object member", ptr)
+	}
+
+	i := parent.member(tok)
+	if i < 0 {
+		return fmt.Errorf("no value at %q", ptr)
+	}
+	if name != tok && parent.member(name) >= 0 {
+		return fmt.Errorf("key %q already exists next to %q", name, ptr)
+	}
+
+	parent.Items[i].Key = quote(name)
+	return nil
+}
+
+// parent returns the object or array holding the value at ptr and the
+// last reference token of ptr
+func (c *CST) parent(ptr string) (*CSTNode, string, error) {
+	i := strings.LastIndexByte(ptr, '/')
+	if i < 0 {
+		return nil, "", fmt.Errorf("invalid pointer %q", ptr)
+	}
+
+	n := c.Root.Lookup(ptr[:i])
+	if n == nil {
+		return nil, "", fmt.Errorf("no value at %q", ptr[:i])
+	}
+	if n.Kind != OBJECT_VALUE && n.Kind != ARRAY_VALUE {
+		return nil, "", fmt.Errorf("value at %q is not an object or array", ptr[:i])
+	}
+	return n, pointerUnescaper.Replace(ptr[i+1:]), nil
+}
+
+// find returns the index of the item of n named by the reference token
+// tok, or -1
+func (n *CSTNode) find(tok string) int {
+	if n.Kind == OBJECT_VALUE {
+		return n.member(tok)
+	}
+	i, ok := pointerIndex(tok, len(n.Items))
+	if !ok {
+		return -1
+	}
+	return i
+}
+
+// insert adds it with value at index of n, laid out like its siblings
+func (c *CST) insert(n *CSTNode, index int, it *CSTItem, value []byte) error {
+	sep := n.separator(c.unit())
+	multi, indent := lineStyle(sep)
+
+	v, err := newCSTValue(value, multi, indent, c.unit())
+	if err != nil {
+		return err
+	}
+	it.Value = v
+
+	// next is the trivia in front of what follows the new item
+	next := &n.End
+	if index < len(n.Items) {
+		next = n.Items[index].leadingPtr()
+	}
+
+	lead := sep
+	switch {
+	case multi:
+		// a comment after the item before stays on its line
+		head, rest := splitLine(*next)
+		lead, *next = head+sep, rest
+	case len(n.Items) == 0:
+		lead = leadingSpace(*next)
+	case index == 0:
+		ws := leadingSpace(*next)
+		lead, *next = ws, sep+(*next)[len(ws):]
+	}
+
+	if it.Key != "" {
+		it.KeyBefore = lead
+		it.Value.Before = " "
+		if len(n.Items) > 0 && isSpace(n.Items[0].Value.Before) {
+			it.Value.Before = n.Items[0].Value.Before
+		}
+	} else {
+		it.Value.Before = lead
+	}
+
+	switch last := len(n.Items) - 1; {
+	case index <= last:
+		it.Comma = true
+	case last >= 0 && n.Items[last].Comma:
+		// keep a trailing comma
+		it.Comma = true
+	case last >= 0:
+		n.Items[last].Comma = true
+	}
+
+	n.Items = slices.Insert(n.Items, index, it)
+	return nil
+}
+
+// newCSTValue parses value for insertion. Objects and arrays that go into
+// a multi-line layout are formatted one item per line at indent.
+func newCSTValue(value []byte, multi bool, indent, unit string) (*CSTNode, error) {
+	text := bytes.TrimSpace(value)
+
+	if multi && len(text) > 0 && (text[0] == '{' || text[0] == '[') {
+		opts := FormatOptions{IndentWidth: len(unit)}
+		if strings.Contains(unit, "\t") {
+			opts = FormatOptions{UseTabs: true}
+		}
+		formatted, err := Format(text, opts)
+		if err != nil {
+			return nil, err
+		}
+		text = bytes.ReplaceAll(formatted, []byte("\n"), []byte("\n"+indent))
+	}
+
+	cst, err := ParseCST(text, CSTOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return cst.Root, nil
+}
+
+// unit returns the indentation of one level, taken from the first
+// multi-line object or array, or two spaces
+func (c *CST) unit() string {
+	var find func(n *CSTNode) string
+	find = func(n *CSTNode) string {
+		if len(n.Items) > 0 {
+			multi, item := lineStyle(n.Items[0].leading())
+			_, close := lineStyle(n.End)
+			if multi && strings.Contains(n.End, "\n") && len(item) > len(close) && strings.HasPrefix(item, close) {
+				return item[len(close):]
+			}
+		}
+		for _, it := range n.Items {
+			if u := find(it.Value); u != "" {
+				return u
+			}
+		}
+		return ""
+	}
+
+	if u := find(c.Root); u != "" {
+		return u
+	}
+	return "  "
+}
+
+// separator returns what goes in front of a new item of n
+func (n *CSTNode) separator(unit string) string {
+	switch len(n.Items) {
+	case 0:
+		if multi, indent := lineStyle(n.End); multi {
+			return "\n" + indent + unit
+		}
+		return ""
+	case 1:
+		if multi, indent := lineStyle(n.Items[0].leading()); multi {
+			return "\n" + indent
+		}
+		return " "
+	}
+
+	lead := n.Items[1].leading()
+	if multi, indent := lineStyle(lead); multi {
+		return "\n" + indent
+	}
+	if isSpace(lead) {
+		return lead
+	}
+	return " "
+}
+
+// text returns the source of n without the trivia in front of it
+func (n *CSTNode) text() string {
+	return strings.TrimPrefix(n.String(), n.Before)
+}
+
+func (it *CSTItem) leading() string {
+	return *it.leadingPtr()
+}
+
+func (it *CSTItem) setLeading(s string) {
+	*it.leadingPtr() = s
+}
+
+// leadingPtr returns the trivia in front of the item, before its key for
+// object members
+func (it *CSTItem) leadingPtr() *string {
+	if it.Key != "" {
+		return &it.KeyBefore
+	}
+	return &it.Value.Before
+}
+
+// lineStyle reports whether trivia ends in a line break and returns the
+// indentation of its last line
+func lineStyle(trivia string) (bool, string) {
+	i := strings.LastIndexByte(trivia, '\n')
+	if i < 0 {
+		return false, ""
+	}
+	return true, leadingSpace(trivia[i+1:])
+}
+
+// splitLine splits trivia before its first line break. Without one, all of
+// it belongs to what follows.
+func splitLine(trivia string) (string, string) {
+	if i := strings.IndexByte(trivia, '\n'); i >= 0 {
+		return trivia[:i], trivia[i:]
+	}
+	return "", trivia
+}
+
+func leadingSpace(s string) string {
+	return s[:len(s)-len(strings.TrimLeft(s, " \t"))]
+}
+
+func isSpace(s string) bool {
+	return strings.Trim(s, " \t\r\n") == ""
+}
@@ -0,0 +1,208 @@
+package parser
+
+import (
+	"testing"
+)
+
+const packageSample = `{
+  // package metadata
+  "name": "demo",
+  "version": "1.2.3", // bumped by the bot
+  "keywords": ["json", "parser"],
+  "scripts": {
+    "test": "go test ./..."
+  }
+}
+`
+
+func mustCST(t *testing.T, input string) *CST {
+	t.Helper()
+	cst, err := ParseCST([]byte(input), CSTOptions{JSONC: true})
+	if err != nil {
+		t.Fatalf("error parsing %v", err)
+	}
+	return cst
+}
+
+func expectDocument(t *testing.T, cst *CST, want string) {
+	t.Helper()
+	if got := cst.String(); got != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, got)
+	}
+	if _, err := ParseCST(cst.Bytes(), CSTOptions{JSONC: true}); err != nil {
+		t.Errorf("edited document does not parse: %v", err)
+	}
+}
+
+func TestSetAt(t *testing.T) {
+	cst := mustCST(t, packageSample)
+
+	if err := cst.SetAt("/version", []byte(`"1.2.4"`)); err != nil {
+		t.Fatalf("error setting %v", err)
+	}
+	if err := cst.SetAt("/scripts/build", []byte(`"go build"`)); err != nil {
+		t.Fatalf("error setting %v", err)
+	}
+	if err := cst.SetAt("/keywords/1", []byte(`"lexer"`)); err != nil {
+		t.Fatalf("error setting %v", err)
+	}
+	if err := cst.SetAt("/engines", []byte(`{"go": ">=1.23", "os": ["linux"]}`)); err != nil {
+		t.Fatalf("error setting %v", err)
+	}
+
+	expectDocument(t, cst, `{
+  // package metadata
+  "name": "demo",
+  "version": "1.2.4", // bumped by the bot
+  "keywords": ["json", "lexer"],
+  "scripts": {
+    "test": "go test ./...",
+    "build": "go build"
+  },
+  "engines": {
+    "go": ">=1.23",
+    "os": [
+      "linux"
+    ]
+  }
+}
+`)
+
+	for _, ptr := range []string{"/keywords/2", "/name/x", "/missing/x", "x"} {
+		if err := cst.SetAt(ptr, []byte(`1`)); err == nil {
+			t.Errorf("%q: error should have been raised", ptr)
+		}
+	}
+	if err := cst.SetAt("/name", []byte(`{"a": `)); err == nil {
+		t.Errorf("error should have been raised")
+	}
+}
+
+func TestSetAtRoot(t *testing.T) {
+	cst := mustCST(t, " [1, 2] \n")
+	if err := cst.SetAt("", []byte(`{"a": 1}`)); err != nil {
+		t.Fatalf("error setting %v", err)
+	}
+	expectDocument(t, cst, " {\"a\": 1} \n")
+}
+
+func TestDeleteAt(t *testing.T) {
+	cst := mustCST(t, packageSample)
+
+	if err := cst.DeleteAt("/name"); err != nil {
+		t.Fatalf("error deleting %v", err)
+	}
+	if err := cst.DeleteAt("/keywords/0"); err != nil {
+		t.Fatalf("error deleting %v", err)
+	}
+	if err := cst.DeleteAt("/scripts"); err != nil {
+		t.Fatalf("error deleting %v", err)
+	}
+
+	expectDocument(t, cst, `{
+  "version": "1.2.3", // bumped by the bot
+  "keywords": ["parser"]
+}
+`)
+
+	if err := cst.DeleteAt("/scripts"); err == nil {
+		t.Errorf("error should have been raised")
+	}
+	if err := cst.DeleteAt(""); err == nil {
+		t.Errorf("error should have been raised")
+	}
+}
+
+func TestDeleteAtComments(t *testing.T) {
+	cst := mustCST(t, `[
+  1, // one
+  2, // two
+  3 // three
+]`)
+
+	if err := cst.DeleteAt("/1"); err != nil {
+		t.Fatalf("error deleting %v", err)
+	}
+	expectDocument(t, cst, `[
+  1, // one
+  3 // three
+]`)
+
+	if err := cst.DeleteAt("/1"); err != nil {
+		t.Fatalf("error deleting %v", err)
+	}
+	expectDocument(t, cst, `[
+  1 // one
+]`)
+
+	if err := cst.DeleteAt("/0"); err != nil {
+		t.Fatalf("error deleting %v", err)
+	}
+	expectDocument(t, cst, `[
+]`)
+}
+
+func TestInsertAt(t *testing.T) {
+	cases := []struct {
+		input string
+		index int
+		value string
+		want  string
+	}{
+		{`[1, 2]`, 0, `0`, `[0, 1, 2]`},
+		{`[1, 2]`, 1, `9`, `[1, 9, 2]`},
+		{`[1, 2]`, 2, `3`, `[1, 2, 3]`},
+		{`[1,2]`, 2, `3`, `[1,2,3]`},
+		{`[ 1 ]`, 0, `0`, `[ 0, 1 ]`},
+		{`[]`, 0, `"a"`, `["a"]`},
+		{`[1, 2,]`, 2, `3`, `[1, 2, 3,]`},
+		{"[\n  1 // one\n]", 1, `2`, "[\n  1, // one\n  2\n]"},
+		{"[\n  1\n]", 0, `0`, "[\n  0,\n  1\n]"},
+		{"{\n  \"a\": [\n  ]\n}", 0, `{"b": 1}`, "{\n  \"a\": [\n    {\n      \"b\": 1\n    }\n  ]\n}"},
+		{"{\n\t\"a\": [\n\t]\n}", 0, `[1]`, "{\n\t\"a\": [\n\t\t[\n\t\t\t1\n\t\t]\n\t]\n}"},
+	}
+
+	for _, c := range cases {
+		cst := mustCST(t, c.input)
+		ptr := ""
+		if cst.Root.Kind == OBJECT_VALUE {
+			ptr = "/a"
+		}
+		if err := cst.InsertAt(ptr, c.index, []byte(c.value)); err != nil {
+			t.Errorf("%q: error inserting %v", c.input, err)
+			continue
+		}
+		expectDocument(t, cst, c.want)
+	}
+
+	cst := mustCST(t, `{"a": [1]}`)
+	for _, c := range []struct {
+		ptr   string
+		index int
+	}{{"/a", 2}, {"/a", -1}, {"", 0}, {"/b", 0}} {
+		if err := cst.InsertAt(c.ptr, c.index, []byte(`1`)); err == nil {
+			t.Errorf("%q %d: error should have been raised", c.ptr, c.index)
+		}
+	}
+}
+
+func TestRenameKey(t *testing.T) {
+	cst := mustCST(t, packageSample)
+
+	if err := cst.RenameKey("/scripts/test", "check"); err != nil {
+		t.Fatalf("error renaming %v", err)
+	}
+	if cst.Root.Lookup("/scripts/check") == nil || cst.Root.Lookup("/scripts/test") != nil {
+		t.Errorf("key was not renamed:\n%s", cst)
+	}
+
+	if err := cst.RenameKey("/name", "version"); err == nil {
+		t.Errorf("error should have been raised")
+	}
+	if err := cst.RenameKey("/keywords/0", "x"); err == nil {
+		t.Errorf("error should have been raised")
+	}
+	if err := cst.RenameKey("/missing", "x"); err == nil {
+		t.Errorf("error should have been raised")
+	}
+}
@@ -0,0 +1,236 @@
+package parser
+
+import (
+	"bytes"
+	"sort"
+	"strings"
+)
+
+type FormatOptions struct {
+	// UseTabs indents with one tab per level instead of spaces.
+	UseTabs bool
+	// IndentWidth is the number of spaces per level, 2 if zero.
+	IndentWidth int
+	// SortKeys orders object members by key. Members with equal keys keep
+	// their relative order.
+	SortKeys bool
+	// LineWidth keeps arrays that only hold scalars on a single line as long
+	// as the line stays within this many bytes. Zero always breaks arrays.
+	LineWidth int
+	// FinalNewline ends the output with a newline.
+	FinalNewline bool
+}
+
+// Format validates input and re-emits it from its tokens with one member or
+// element per line. Strings and numbers are written exactly as they appear
+// in the input.
+func Format(input []byte, opts FormatOptions) ([]byte, error) {
+	p, err := NewParser(input)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := p.Parse(); err != nil {
+		return nil, err
+	}
+
+	return FormatTokens(p.tokens, opts), nil
+}
+
+// FormatTokens formats an already validated token stream.
+func FormatTokens(tokens []Token, opts FormatOptions) []byte {
+	f := &formatter{tokens: tokens, opts: opts}
+
+	f.indent = strings.Repeat(" ", 2)
+	if opts.UseTabs {
+		f.indent = "\t"
+	} else if opts.IndentWidth > 0 {
+		f.indent = strings.Repeat(" ", opts.IndentWidth)
+	}
+
+	if len(tokens) > 0 && tokens[0].TokenType != EOF {
+		f.value(0, 0)
+	}
+
+	if opts.FinalNewline {
+		f.buf.WriteByte('\n')
+	}
+	return f.buf.Bytes()
+}
+
+type formatter struct {
+	tokens []Token
+	opts   FormatOptions
+	indent string
+	buf    bytes.Buffer
+}
+
+// member is an object member as token indices, key is the decoded key used
+// for sorting
+type member struct {
+	key   string
+	keyAt int
+	at    int
+}
+
+// value writes the value starting at token i and returns the index of the
+// token that follows it
+func (f *formatter) value(i, depth int) int {
+	switch f.tokens[i].TokenType {
+	case LEFT_BRACKET:
+		return f.array(i, depth)
+	case LEFT_BRACE:
+		return f.object(i, depth)
+	default:
+		f.buf.WriteString(tokenText(f.tokens[i]))
+		return i + 1
+	}
+}
+
+func (f *formatter) array(i, depth int) int {
+	elems := []int{}
+	next := i + 1
+	for f.tokens[next].TokenType != RIGHT_BRACKET {
+		elems = append(elems, next)
+		next = skipValue(f.tokens, next)
+		if f.tokens[next].TokenType == COMMA {
+			next++
+		}
+	}
+
+	if len(elems) == 0 {
+		f.buf.WriteString("[]")
+		return next + 1
+	}
+
+	if line, ok := f.compactArray(elems); ok {
+		f.buf.WriteString(line)
+		return next + 1
+	}
+
+	f.buf.WriteByte('[')
+	for n, e := range elems {
+		if n > 0 {
+			f.buf.WriteByte(',')
+		}
+		f.newline(depth + 1)
+		f.value(e, depth+1)
+	}
+	f.newline(depth)
+	f.buf.WriteByte(']')
+
+	return next + 1
+}
+
+// compactArray renders an array of scalars on one line if it fits
+func (f *formatter) compactArray(elems []int) (string, bool) {
+	if f.opts.LineWidth <= 0 {
+		return "", false
+	}
+
+	var sb strings.Builder
+	sb.WriteByte('[')
+	for n, e := range elems {
+		t := f.tokens[e].TokenType
+		if t == LEFT_BRACKET || t == LEFT_BRACE {
+			return "", false
+		}
+		if n > 0 {
+			sb.WriteString(", ")
+		}
+		sb.WriteString(tokenText(f.tokens[e]))
+	}
+	sb.WriteByte(']')
+
+	if f.column()+sb.Len() > f.opts.LineWidth {
+		return "", false
+	}
+	return sb.String(), true
+}
+
+func (f *formatter) object(i, depth int) int {
+	members := []member{}
+	next := i + 1
+	for f.tokens[next].TokenType != RIGHT_BRACE {
+		key, _ := unquote(f.tokens[next].Value)
+		members = append(members, member{key: key, keyAt: next, at: next + 2})
+		next = skipValue(f.tokens, next+2)
+		if f.tokens[next].TokenType == COMMA {
+			next++
+		}
+	}
+
+	if len(members) == 0 {
+		f.buf.WriteString("{}")
+		return next + 1
+	}
+
+	if f.opts.SortKeys {
+		sort.SliceStable(members, func(a, b int) bool {
+			return members[a].key < members[b].key
+		})
+	}
+
+	f.buf.WriteByte('{')
+	for n, m := range members {
+		if n > 0 {
+			f.buf.WriteByte(',')
+		}
+		f.newline(depth + 1)
+		f.buf.WriteString(tokenText(f.tokens[m.keyAt]))
+		f.buf.WriteString(": ")
+		f.value(m.at, depth+1)
+	}
+	f.newline(depth)
+	f.buf.WriteByte('}')
+
+	return next + 1
+}
+
+func (f *formatter) newline(depth int) {
+	f.buf.WriteByte('\n')
+	for i := 0; i < depth; i++ {
+		f.buf.WriteString(f.indent)
+	}
+}
+
+// column is the width of the current output line, counting a tab as the
+// configured indent width
+func (f *formatter) column() int {
+	b := f.buf.Bytes()
+	line := b[bytes.LastIndexByte(b, '\n')+1:]
+	tabs := bytes.Count(line, []byte{'\t'})
+	width := f.opts.IndentWidth
+	if width <= 0 {
+		width = 2
+	}
+	return len(line) - tabs + tabs*width
+}
+
+// skipValue returns the index of the token after the value starting at i
+func skipValue(tokens []Token, i int) int {
+	depth := 0
+	for ; i < len(tokens); i++ {
+		switch tokens[i].TokenType {
+		case LEFT_BRACE, LEFT_BRACKET:
+			depth++
+		case RIGHT_BRACE, RIGHT_BRACKET:
+			depth--
+		case EOF:
+			return i
+		}
+		if depth == 0 {
+			return i + 1
+		}
+	}
+	return i
+}
+
+// tokenText is the source text of a token; string values are stored
+// without their quotes
+func tokenText(t Token) string {
+	if t.TokenType == STRING {
+		return `"` + t.Value + `"`
+	}
+	return t.Value
+}
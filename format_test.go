@@ -0,0 +1,86 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestFormatDefault(t *testing.T) {
+	sample := []byte(`{"b":[1,2,{"x":null}],"a":{},"c":[],"d":"s\"q"}`)
+
+	out, err := Format(sample, FormatOptions{})
+	if err != nil {
+		t.Fatalf("error formatting %v", err)
+	}
+
+	want := "{\n  \"b\": [\n    1,\n    2,\n    {\n      \"x\": null\n    }\n  ],\n  \"a\": {},\n  \"c\": [],\n  \"d\": \"s\\\"q\"\n}"
+	if string(out) != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, out)
+	}
+}
+
+func TestFormatOptions(t *testing.T) {
+	sample := []byte(`{"z": [1, 2, 3], "a": {"long": ["aaaaaaaaaa", "bbbbbbbbbb", "cccccccccc"], "k": true}}`)
+
+	out, err := Format(sample, FormatOptions{UseTabs: true, IndentWidth: 4, SortKeys: true, LineWidth: 40, FinalNewline: true})
+	if err != nil {
+		t.Fatalf("error formatting %v", err)
+	}
+
+	want := "{\n\t\"a\": {\n\t\t\"k\": true,\n\t\t\"long\": [\n\t\t\t\"aaaaaaaaaa\",\n\t\t\t\"bbbbbbbbbb\",\n\t\t\t\"cccccccccc\"\n\t\t]\n\t},\n\t\"z\": [1, 2, 3]\n}\n"
+	if string(out) != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, out)
+	}
+}
+
+func TestFormatScalar(t *testing.T) {
+	out, err := Format([]byte(" 12.50e3 "), FormatOptions{IndentWidth: 4, FinalNewline: true})
+	if err != nil {
+		t.Fatalf("error formatting %v", err)
+	}
+
+	if string(out) != "12.50e3\n" {
+		t.Errorf("unexpected output %q", out)
+	}
+}
+
+func TestFormatInvalid(t *testing.T) {
+	_, err := Format([]byte(`{"a": [1, 2}`), FormatOptions{})
+
+	if err == nil {
+		t.Errorf("error should have been raised")
+	}
+}
+
+func TestFormatIdempotent(t *testing.T) {
+	files, _ := filepath.Glob("./main/testpass/*.json")
+
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("error reading %s: %v", file, err)
+		}
+
+		opts := FormatOptions{SortKeys: true, LineWidth: 80, FinalNewline: true}
+		once, err := Format(content, opts)
+		if err != nil {
+			t.Fatalf("error formatting %s: %v", file, err)
+		}
+
+		twice, err := Format(once, opts)
+		if err != nil {
+			t.Fatalf("error formatting %s again: %v", file, err)
+		}
+
+		if string(once) != string(twice) {
+			t.Errorf("%s: formatting is not stable", file)
+		}
+
+		a, _ := ParseValue(content)
+		b, _ := ParseValue(once)
+		if !Equal(a, b) {
+			t.Errorf("%s: formatting changed the document", file)
+		}
+	}
+}
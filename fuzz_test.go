@@ -0,0 +1,113 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// addSeeds seeds a fuzz target with the json.org fixtures in main/
+func addSeeds(f *testing.F) {
+	files, _ := filepath.Glob("./main/test*/*.json")
+	for _, file := range files {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			f.Fatalf("error reading %s: %v", file, err)
+		}
+		f.Add(content)
+	}
+
+	for _, s := range []string{"", "{", "}", "[", "]", "[{]", "{[}", "{\"a\"", "{\"a\":", "[1,", "\"", "-", "0"} {
+		f.Add([]byte(s))
+	}
+}
+
+func FuzzTokenize(f *testing.F) {
+	addSeeds(f)
+
+	f.Fuzz(func(t *testing.T, input []byte) {
+		tokens, err := NewLexer(bufio.NewReader(bytes.NewReader(input))).Tokenize()
+		if err != nil {
+			return
+		}
+
+		if len(tokens) == 0 || tokens[len(tokens)-1].TokenType != EOF {
+			t.Fatalf("token stream does not end with EOF: %v", tokens)
+		}
+
+		for i := 1; i < len(tokens); i++ {
+			if tokens[i].Pos.Offset < tokens[i-1].Pos.Offset {
+				t.Fatalf("token offsets go backwards: %v", tokens)
+			}
+		}
+
+		// the in-memory lexer must produce the same stream
+		fromBytes, err := NewLexerBytes(input).Tokenize()
+		if err != nil {
+			t.Fatalf("in-memory lexer rejects input: %v", err)
+		}
+		if !reflect.DeepEqual(tokens, fromBytes) {
+			t.Fatalf("lexers disagree:\n%v\n%v", tokens, fromBytes)
+		}
+	})
+}
+
+func FuzzParse(f *testing.F) {
+	addSeeds(f)
+
+	f.Fuzz(func(t *testing.T, input []byte) {
+		p, err := NewParser(input)
+		if err != nil {
+			return
+		}
+
+		ok, err := p.Parse()
+		if ok != (err == nil) {
+			t.Fatalf("Parse returned %v with error %v", ok, err)
+		}
+		if err != nil {
+			return
+		}
+
+		// anything the parser accepts must decode
+		if _, err := p.Value(); err != nil {
+			t.Fatalf("accepted document does not decode: %v", err)
+		}
+	})
+}
+
+func FuzzRoundTrip(f *testing.F) {
+	addSeeds(f)
+
+	f.Fuzz(func(t *testing.T, input []byte) {
+		before, err := ParseValue(input)
+		if err != nil {
+			return
+		}
+
+		formatted, err := Format(input, FormatOptions{SortKeys: true, LineWidth: 40})
+		if err != nil {
+			t.Fatalf("valid document does not format: %v", err)
+		}
+
+		after, err := ParseValue(formatted)
+		if err != nil {
+			t.Fatalf("formatted document does not parse: %v\n%s", err, formatted)
+		}
+
+		if !Equal(before, after) {
+			t.Fatalf("formatting changed the document:\n%s\n%s", input, formatted)
+		}
+
+		cst, err := ParseCST(input, CSTOptions{JSONC: true})
+		if err != nil {
+			t.Fatalf("valid document has no syntax tree: %v", err)
+		}
+		if !bytes.Equal(cst.Bytes(), input) {
+			t.Fatalf("syntax tree changed the document:\n%s\n%s", input, cst.Bytes())
+		}
+	})
+}
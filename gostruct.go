@@ -0,0 +1,260 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type GoStructOptions struct {
+	// Package adds a package clause to the output when set.
+	Package string
+	// TypeName is the name of the top level type, "Root" if empty.
+	TypeName string
+}
+
+// GenerateGoStructs parses the samples and returns Go type definitions that
+// can hold all of them, see GenerateGoStructsValues.
+func GenerateGoStructs(opts GoStructOptions, samples ...[]byte) ([]byte, error) {
+	values := make([]*Value, 0, len(samples))
+	for _, s := range samples {
+		v, err := ParseValue(s)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return GenerateGoStructsValues(opts, values...)
+}
+
+// GenerateGoStructsValues returns gofmt-ed Go source declaring a type for
+// the samples. Objects become structs with json tags (nested objects get
+// their own named types), homogeneous arrays become slices, values that are
+// sometimes null become pointers, and numbers are int64 unless a sample
+// was written with a fraction or exponent or does not fit, in which case
+// they are uint64 or float64. Keys missing from some samples are tagged
+// omitempty.
+func GenerateGoStructsValues(opts GoStructOptions, samples ...*Value) ([]byte, error) {
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no samples")
+	}
+
+	s := newShape()
+	for _, v := range samples {
+		s.add(v)
+	}
+
+	name := opts.TypeName
+	if name == "" {
+		name = "Root"
+	}
+
+	g := &goGenerator{names: map[string]bool{}}
+	g.names[name] = true
+
+	var root bytes.Buffer
+	if s.objects > 0 && len(s.types) == 1 {
+		g.writeStruct(&root, name, s)
+	} else {
+		fmt.Fprintf(&root, "type %s %s\n", name, g.typeOf(s, name, false))
+	}
+
+	var out bytes.Buffer
+	if opts.Package != "" {
+		fmt.Fprintf(&out, "package %s\n\n", opts.Package)
+	}
+	out.Write(root.Bytes())
+	for _, def := range g.defs {
+		out.WriteByte('\n')
+		out.Write(def)
+	}
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("formatting generated code: %v", err)
+	}
+	return src, nil
+}
+
+type goGenerator struct {
+	names map[string]bool
+	defs  [][]byte
+}
+
+// typeOf returns the Go type for a shape, declaring struct types named
+// after hint as needed
+func (g *goGenerator) typeOf(s *shape, hint string, nullable bool) string {
+	types := make([]string, 0, len(s.types))
+	for t := range s.types {
+		if t != "null" {
+			types = append(types, t)
+		}
+	}
+	if s.types["null"] {
+		nullable = true
+	}
+
+	if len(types) == 2 && s.types["integer"] && s.types["number"] {
+		types = []string{"number"}
+	}
+	if len(types) != 1 {
+		return "any"
+	}
+
+	var t string
+	switch types[0] {
+	case "boolean":
+		t = "bool"
+	case "string":
+		t = "string"
+	case "integer", "number":
+		t = s.intType()
+	case "array":
+		elem := "any"
+		if s.items != nil {
+			elem = g.typeOf(s.items, singular(hint), false)
+		}
+		// nil already represents a missing slice, no pointer needed
+		return "[]" + elem
+	case "object":
+		t = g.uniqueName(hint)
+		// reserve the slot first so parents are declared before children
+		idx := len(g.defs)
+		g.defs = append(g.defs, nil)
+		var def bytes.Buffer
+		g.writeStruct(&def, t, s)
+		g.defs[idx] = def.Bytes()
+	}
+
+	if nullable {
+		return "*" + t
+	}
+	return t
+}
+
+func (g *goGenerator) writeStruct(w *bytes.Buffer, name string, s *shape) {
+	var fields bytes.Buffer
+	used := map[string]bool{}
+
+	for _, k := range s.keys {
+		if !validJSONTag(k) {
+			// encoding/json would read a different name out of the tag
+			fmt.Fprintf(&fields, "\t// key %s cannot be named in a json tag\n", strconv.Quote(k))
+			continue
+		}
+
+		p := s.props[k]
+		field := goFieldName(k)
+		for i := 2; used[field]; i++ {
+			field = fmt.Sprintf("%s%d", goFieldName(k), i)
+		}
+		used[field] = true
+
+		tag := k
+		if p.count < s.objects {
+			tag += ",omitempty"
+		} else if k == "-" {
+			// a bare "-" skips the field
+			tag += ","
+		}
+		fmt.Fprintf(&fields, "\t%s %s `json:%s`\n", field, g.typeOf(p, name+field, false), strconv.Quote(tag))
+	}
+
+	fmt.Fprintf(w, "type %s struct {\n%s}\n", name, fields.String())
+}
+
+// intType returns the Go type for the numbers of a shape: int64 if they
+// were all written as integers in its range, uint64 if they only fit
+// that, and float64 otherwise
+func (s *shape) intType() string {
+	if s.fraction {
+		return "float64"
+	}
+	if _, err := strconv.ParseInt(s.min.Number, 10, 64); err == nil {
+		if _, err := strconv.ParseInt(s.max.Number, 10, 64); err == nil {
+			return "int64"
+		}
+	}
+	if _, err := strconv.ParseUint(s.min.Number, 10, 64); err == nil {
+		if _, err := strconv.ParseUint(s.max.Number, 10, 64); err == nil {
+			return "uint64"
+		}
+	}
+	return "float64"
+}
+
+// validJSONTag reports whether encoding/json takes key as the name in a
+// struct tag. It rejects empty names and everything but letters, digits,
+// spaces and the punctuation it allows, e.g. commas and quotes.
+func validJSONTag(key string) bool {
+	if key == "" {
+		return false
+	}
+	for _, r := range key {
+		switch {
+		case strings.ContainsRune("!#$%&()*+-./:;<=>?@[]^_{|}~ ", r):
+		case unicode.IsLetter(r), unicode.IsDigit(r):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func (g *goGenerator) uniqueName(name string) string {
+	unique := name
+	for i := 2; g.names[unique]; i++ {
+		unique = fmt.Sprintf("%s%d", name, i)
+	}
+	g.names[unique] = true
+	return unique
+}
+
+var goInitialisms = map[string]bool{
+	"API": true, "HTML": true, "HTTP": true, "HTTPS": true, "ID": true, "IP": true,
+	"JSON": true, "SQL": true, "URI": true, "URL": true, "UUID": true, "XML": true,
+}
+
+// goFieldName turns a JSON key into an exported Go identifier, e.g.
+// "user_id" -> "UserID", "first-name" -> "FirstName"
+func goFieldName(key string) string {
+	words := strings.FieldsFunc(key, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+
+	var sb strings.Builder
+	for _, w := range words {
+		if goInitialisms[strings.ToUpper(w)] {
+			sb.WriteString(strings.ToUpper(w))
+			continue
+		}
+		r := []rune(w)
+		r[0] = unicode.ToUpper(r[0])
+		sb.WriteString(string(r))
+	}
+
+	name := sb.String()
+	if name == "" {
+		return "Field"
+	}
+	// digits and letters without case, e.g. in 名前, do not export
+	if !unicode.IsUpper([]rune(name)[0]) {
+		name = "F" + name
+	}
+	return name
+}
+
+func singular(name string) string {
+	switch {
+	case strings.HasSuffix(name, "ies"):
+		return strings.TrimSuffix(name, "ies") + "y"
+	case strings.HasSuffix(name, "ss"):
+		return name + "Item"
+	case strings.HasSuffix(name, "s"):
+		return strings.TrimSuffix(name, "s")
+	}
+	return name + "Item"
+}
@@ -0,0 +1,172 @@
+package parser
+
+import (
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"reflect"
+	"strconv"
+	"testing"
+)
+
+func TestGenerateGoStructs(t *testing.T) {
+	src, err := GenerateGoStructs(GoStructOptions{Package: "model", TypeName: "User"},
+		[]byte(`{"user_id": 1, "name": "a", "score": 1.5, "address": {"city": "x", "zip": null}, "tags": ["a"], "friends": [{"id": 2}], "avatar_url": null}`),
+		[]byte(`{"user_id": 2, "name": "b", "score": 2, "address": {"city": "y", "zip": "123"}, "tags": [], "friends": [], "avatar_url": "http://x", "extra": true, "mixed": [1, "a"]}`),
+	)
+	if err != nil {
+		t.Fatalf("error generating %v", err)
+	}
+
+	want := `package model
+
+type User struct {
+	UserID    int64        ` + "`json:\"user_id\"`" + `
+	Name      string       ` + "`json:\"name\"`" + `
+	Score     float64      ` + "`json:\"score\"`" + `
+	Address   UserAddress  ` + "`json:\"address\"`" + `
+	Tags      []string     ` + "`json:\"tags\"`" + `
+	Friends   []UserFriend ` + "`json:\"friends\"`" + `
+	AvatarURL *string      ` + "`json:\"avatar_url\"`" + `
+	Extra     bool         ` + "`json:\"extra,omitempty\"`" + `
+	Mixed     []any        ` + "`json:\"mixed,omitempty\"`" + `
+}
+
+type UserAddress struct {
+	City string  ` + "`json:\"city\"`" + `
+	Zip  *string ` + "`json:\"zip\"`" + `
+}
+
+type UserFriend struct {
+	ID int64 ` + "`json:\"id\"`" + `
+}
+`
+
+	if string(src) != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, src)
+	}
+}
+
+func TestGenerateGoStructsArrayRoot(t *testing.T) {
+	src, err := GenerateGoStructs(GoStructOptions{TypeName: "Entries"}, []byte(`[{"a-b": 1, "2x": {"k": true}}]`))
+	if err != nil {
+		t.Fatalf("error generating %v", err)
+	}
+
+	want := "type Entries []Entry\n\ntype Entry struct {\n\tAB  int64    `json:\"a-b\"`\n\tF2x EntryF2x `json:\"2x\"`\n}\n\ntype EntryF2x struct {\n\tK bool `json:\"k\"`\n}\n"
+
+	if string(src) != want {
+		t.Errorf("expected\n%q\ngot\n%q", want, src)
+	}
+}
+
+func TestGoFieldName(t *testing.T) {
+	cases := map[string]string{
+		"user_id":    "UserID",
+		"first-name": "FirstName",
+		"URL":        "URL",
+		"":           "Field",
+		"9lives":     "F9lives",
+		"camelCase":  "CamelCase",
+		"名前":         "F名前",
+		"ölçü":       "Ölçü",
+	}
+
+	for in, want := range cases {
+		if got := goFieldName(in); got != want {
+			t.Errorf("%q: expected %s, got %s", in, want, got)
+		}
+	}
+}
+
+// generatedStruct parses generated code and builds its single struct type
+// with reflect, so samples can be run through encoding/json
+func generatedStruct(t *testing.T, src []byte) (reflect.Type, *ast.File) {
+	t.Helper()
+	f, err := parser.ParseFile(token.NewFileSet(), "", src, parser.ParseComments)
+	if err != nil {
+		t.Fatalf("generated code does not parse: %v", err)
+	}
+
+	types := map[string]reflect.Type{
+		"int64":   reflect.TypeFor[int64](),
+		"uint64":  reflect.TypeFor[uint64](),
+		"float64": reflect.TypeFor[float64](),
+		"string":  reflect.TypeFor[string](),
+		"bool":    reflect.TypeFor[bool](),
+	}
+
+	var fields []reflect.StructField
+	ast.Inspect(f, func(n ast.Node) bool {
+		if field, ok := n.(*ast.Field); ok && field.Tag != nil {
+			typ := types[fmt.Sprint(field.Type)]
+			if typ == nil {
+				t.Fatalf("unexpected field type %v", field.Type)
+			}
+			tag, _ := strconv.Unquote(field.Tag.Value)
+			fields = append(fields, reflect.StructField{
+				Name: field.Names[0].Name,
+				Type: typ,
+				Tag:  reflect.StructTag(tag),
+			})
+		}
+		return true
+	})
+	return reflect.StructOf(fields), f
+}
+
+func TestGenerateGoStructsTags(t *testing.T) {
+	sample := `{"-": 1, "a b": 2, "a,b": 3, "": 4, "say \"hi\"": 5, "back` + "`" + `tick": 6, "ok": 7, "名前": 8}`
+	src, err := GenerateGoStructs(GoStructOptions{Package: "model"}, []byte(sample))
+	if err != nil {
+		t.Fatalf("error generating %v", err)
+	}
+
+	// round trip the sample through the struct with the generated tags
+	typ, f := generatedStruct(t, src)
+	v := reflect.New(typ)
+	if err := json.Unmarshal([]byte(sample), v.Interface()); err != nil {
+		t.Fatalf("error unmarshaling %v", err)
+	}
+	out, err := json.Marshal(v.Interface())
+	if err != nil {
+		t.Fatalf("error marshaling %v", err)
+	}
+
+	want := `{"-":1,"a b":2,"ok":7,"名前":8}`
+	if string(out) != want {
+		t.Errorf("expected %s, got %s\n%s", want, out, src)
+	}
+	comments := 0
+	for _, g := range f.Comments {
+		comments += len(g.List)
+	}
+	if c := comments; c != 4 {
+		t.Errorf("expected a comment for each of the 4 left out keys, got %d\n%s", c, src)
+	}
+}
+
+func TestGenerateGoStructsNumbers(t *testing.T) {
+	samples := [][]byte{
+		[]byte(`{"small": 1, "id": 12345678901234567890, "big": 12345678901234567890, "neg": -1, "ratio": 1}`),
+		[]byte(`{"small": -9223372036854775808, "id": 1, "big": -1, "neg": -99999999999999999999, "ratio": 0.5}`),
+	}
+	src, err := GenerateGoStructs(GoStructOptions{Package: "model"}, samples...)
+	if err != nil {
+		t.Fatalf("error generating %v", err)
+	}
+
+	want := "package model\n\ntype Root struct {\n\tSmall int64   `json:\"small\"`\n\tID    uint64  `json:\"id\"`\n\tBig   float64 `json:\"big\"`\n\tNeg   float64 `json:\"neg\"`\n\tRatio float64 `json:\"ratio\"`\n}\n"
+	if string(src) != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, src)
+	}
+
+	typ, _ := generatedStruct(t, src)
+	for _, sample := range samples {
+		if err := json.Unmarshal(sample, reflect.New(typ).Interface()); err != nil {
+			t.Errorf("%s: error unmarshaling %v", sample, err)
+		}
+	}
+}
@@ -0,0 +1,205 @@
+package parser
+
+import (
+	"sort"
+	"strings"
+)
+
+const (
+	schemaDialect = "https://json-schema.org/draft/2020-12/schema"
+
+	// inferEnumLimit is the largest number of distinct strings that is
+	// still described as an enum
+	inferEnumLimit = 5
+)
+
+// InferSchema parses each sample and returns a JSON Schema describing all
+// of them, see InferSchemaValues.
+func InferSchema(samples ...[]byte) (*Value, error) {
+	values := make([]*Value, 0, len(samples))
+	for _, s := range samples {
+		v, err := ParseValue(s)
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, v)
+	}
+	return InferSchemaValues(values...), nil
+}
+
+// InferSchemaValues returns a JSON Schema that every sample satisfies.
+// Types seen at the same place are merged, object keys present in every
+// sample are required, numbers get their observed range and strings with
+// few distinct values that repeat across samples become enums.
+func InferSchemaValues(samples ...*Value) *Value {
+	s := newShape()
+	for _, v := range samples {
+		s.add(v)
+	}
+
+	schema := s.schema()
+	schema.Members = append([]Member{{Key: "$schema", Value: stringValue(schemaDialect)}}, schema.Members...)
+	return schema
+}
+
+// shape accumulates everything seen at one location of the samples
+type shape struct {
+	count   int
+	types   map[string]bool
+	strings []string
+	seen    map[string]bool
+	nstr    int
+	min     *Value
+	max     *Value
+	// fraction is set once a number literal with a fraction or exponent
+	// is seen, even if its value is integral
+	fraction bool
+	objects  int
+	keys     []string
+	props    map[string]*shape
+	items    *shape
+}
+
+func newShape() *shape {
+	return &shape{
+		types: map[string]bool{},
+		seen:  map[string]bool{},
+		props: map[string]*shape{},
+	}
+}
+
+func (s *shape) add(v *Value) {
+	s.count++
+
+	switch v.Kind {
+	case NULL_VALUE, BOOL_VALUE:
+		s.types[v.Kind.String()] = true
+	case NUMBER_VALUE:
+		r, _ := numberRat(v)
+		if r != nil && r.IsInt() {
+			s.types["integer"] = true
+		} else {
+			s.types["number"] = true
+		}
+		if strings.ContainsAny(v.Number, ".eE") {
+			s.fraction = true
+		}
+		if s.min == nil || compareNumbers(v, s.min) < 0 {
+			s.min = v
+		}
+		if s.max == nil || compareNumbers(v, s.max) > 0 {
+			s.max = v
+		}
+	case STRING_VALUE:
+		s.types["string"] = true
+		s.nstr++
+		if !s.seen[v.Str] {
+			s.seen[v.Str] = true
+			s.strings = append(s.strings, v.Str)
+		}
+	case ARRAY_VALUE:
+		s.types["array"] = true
+		for _, e := range v.Elems {
+			if s.items == nil {
+				s.items = newShape()
+			}
+			s.items.add(e)
+		}
+	case OBJECT_VALUE:
+		s.types["object"] = true
+		s.objects++
+		for _, m := range uniqueMembers(v) {
+			p, ok := s.props[m.Key]
+			if !ok {
+				p = newShape()
+				s.props[m.Key] = p
+				s.keys = append(s.keys, m.Key)
+			}
+			p.add(m.Value)
+		}
+	}
+}
+
+func compareNumbers(a, b *Value) int {
+	ra, okA := numberRat(a)
+	rb, okB := numberRat(b)
+	if !okA || !okB {
+		return 0
+	}
+	return ra.Cmp(rb)
+}
+
+func (s *shape) schema() *Value {
+	out := &Value{Kind: OBJECT_VALUE, Members: []Member{}}
+	add := func(key string, v *Value) {
+		out.Members = append(out.Members, Member{Key: key, Value: v})
+	}
+
+	// integers are numbers, so a location that saw both is just a number
+	if s.types["number"] {
+		delete(s.types, "integer")
+	}
+
+	types := make([]string, 0, len(s.types))
+	for t := range s.types {
+		types = append(types, t)
+	}
+	sort.Strings(types)
+
+	switch len(types) {
+	case 0:
+		return out
+	case 1:
+		add("type", stringValue(types[0]))
+	default:
+		list := &Value{Kind: ARRAY_VALUE}
+		for _, t := range types {
+			list.Elems = append(list.Elems, stringValue(t))
+		}
+		add("type", list)
+	}
+
+	if s.min != nil {
+		add("minimum", &Value{Kind: NUMBER_VALUE, Number: s.min.Number})
+		add("maximum", &Value{Kind: NUMBER_VALUE, Number: s.max.Number})
+	}
+
+	// an enum would reject the other types, so only strings (and null) get one
+	onlyStrings := len(types) == 1 || (len(types) == 2 && s.types["null"])
+	if onlyStrings && len(s.strings) > 0 && len(s.strings) <= inferEnumLimit && s.nstr >= 2*len(s.strings) {
+		enum := &Value{Kind: ARRAY_VALUE}
+		for _, str := range s.strings {
+			enum.Elems = append(enum.Elems, stringValue(str))
+		}
+		if s.types["null"] {
+			enum.Elems = append(enum.Elems, &Value{Kind: NULL_VALUE})
+		}
+		add("enum", enum)
+	}
+
+	if s.items != nil {
+		add("items", s.items.schema())
+	}
+
+	if s.objects > 0 {
+		props := &Value{Kind: OBJECT_VALUE, Members: []Member{}}
+		required := &Value{Kind: ARRAY_VALUE, Elems: []*Value{}}
+		for _, k := range s.keys {
+			p := s.props[k]
+			props.Members = append(props.Members, Member{Key: k, Value: p.schema()})
+			if p.count == s.objects {
+				required.Elems = append(required.Elems, stringValue(k))
+			}
+		}
+		add("properties", props)
+		if len(required.Elems) > 0 {
+			add("required", required)
+		}
+	}
+
+	return out
+}
+
+func stringValue(s string) *Value {
+	return &Value{Kind: STRING_VALUE, Str: s}
+}
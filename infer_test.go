@@ -0,0 +1,70 @@
+package parser
+
+import "testing"
+
+func TestInferSchema(t *testing.T) {
+	schema, err := InferSchema(
+		[]byte(`{"id": 1, "status": "open", "score": 2.5, "tags": ["a"], "owner": null}`),
+		[]byte(`{"id": 7, "status": "closed", "score": 3, "tags": [], "owner": {"name": "x"}}`),
+		[]byte(`{"id": 3, "status": "open", "tags": ["b", "c"], "owner": null}`),
+		[]byte(`{"id": 4, "status": "closed", "tags": [], "owner": null}`),
+	)
+	if err != nil {
+		t.Fatalf("error inferring %v", err)
+	}
+
+	want := `{"$schema":"https://json-schema.org/draft/2020-12/schema","type":"object","properties":{` +
+		`"id":{"type":"integer","minimum":1,"maximum":7},` +
+		`"status":{"type":"string","enum":["open","closed"]},` +
+		`"score":{"type":"number","minimum":2.5,"maximum":3},` +
+		`"tags":{"type":"array","items":{"type":"string"}},` +
+		`"owner":{"type":["null","object"],"properties":{"name":{"type":"string"}},"required":["name"]}},` +
+		`"required":["id","status","tags","owner"]}`
+
+	if s := schema.String(); s != want {
+		t.Errorf("expected\n%s\ngot\n%s", want, s)
+	}
+}
+
+func TestInferSchemaValidatesSamples(t *testing.T) {
+	samples := [][]byte{
+		[]byte(`[{"a": 1, "b": "x"}, {"a": -2.5}]`),
+		[]byte(`[{"a": 10, "c": [true, null]}]`),
+	}
+
+	schema, err := InferSchema(samples...)
+	if err != nil {
+		t.Fatalf("error inferring %v", err)
+	}
+
+	compiled, err := CompileSchema([]byte(schema.String()))
+	if err != nil {
+		t.Fatalf("error compiling inferred schema %v", err)
+	}
+
+	for _, s := range samples {
+		errs, err := compiled.Validate(s)
+		if err != nil || len(errs) != 0 {
+			t.Errorf("sample %s does not match its schema: %v %v", s, err, errs)
+		}
+	}
+}
+
+func TestInferSchemaInvalidSample(t *testing.T) {
+	_, err := InferSchema([]byte(`{}`), []byte(`{`))
+
+	if err == nil {
+		t.Errorf("error should have been raised")
+	}
+}
+
+func TestInferSchemaMixedTypesNoEnum(t *testing.T) {
+	schema, err := InferSchema([]byte(`["a", "a", 1, "a"]`))
+	if err != nil {
+		t.Fatalf("error inferring %v", err)
+	}
+
+	if s := schema.Get("items").String(); s != `{"type":["integer","string"],"minimum":1,"maximum":1}` {
+		t.Errorf("unexpected items schema %s", s)
+	}
+}
@@ -0,0 +1,309 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"iter"
+)
+
+// Tokens returns an iterator over the tokens of the document read from r,
+// without the final EOF token. Tokens are checked for structure as they
+// are read; the first error is yielded with an empty token and ends the
+// sequence.
+func Tokens(r io.Reader) iter.Seq2[Token, error] {
+	return func(yield func(Token, error) bool) {
+		s := NewStream(r)
+		for {
+			t, err := s.next()
+			if err != nil {
+				yield(Token{}, err)
+				return
+			}
+			if t.TokenType == EOF || !yield(t, nil) {
+				return
+			}
+		}
+	}
+}
+
+// ArrayElements returns an iterator over the elements of the top level
+// array read from r, decoding one at a time. Like Tokens it yields the
+// first error with a nil value and ends the sequence, so the loop is
+//
+//	for rec, err := range ArrayElements(r)
+//
+// and the first variable is the element, not its index. For the index use
+// a Stream and check Err after the loop:
+//
+//	s := NewStream(r)
+//	for i, rec := range s.ArrayElements() {
+//		...
+//	}
+//	err := s.Err()
+func ArrayElements(r io.Reader) iter.Seq2[*Value, error] {
+	return func(yield func(*Value, error) bool) {
+		s := NewStream(r)
+		for _, v := range s.ArrayElements() {
+			if !yield(v, nil) {
+				return
+			}
+		}
+		if s.err != nil {
+			yield(nil, s.err)
+		}
+	}
+}
+
+// ObjectMembers returns an iterator over the members of the top level
+// object read from r, yielding the first error like ArrayElements. For
+// key and value as separate loop variables use NewStream(r).ObjectMembers
+// and Err.
+func ObjectMembers(r io.Reader) iter.Seq2[Member, error] {
+	return func(yield func(Member, error) bool) {
+		s := NewStream(r)
+		for m := range s.members() {
+			if !yield(m, nil) {
+				return
+			}
+		}
+		if s.err != nil {
+			yield(Member{}, s.err)
+		}
+	}
+}
+
+// Stream reads a single document from a reader one element at a time, so
+// a huge top level array or object can be processed without holding all
+// of it in memory. A Stream can only be iterated once; check Err after
+// the loop.
+type Stream struct {
+	lexer   *Lexer
+	checker *syntaxChecker
+	err     error
+}
+
+func NewStream(r io.Reader) *Stream {
+	return &Stream{
+		lexer:   NewLexer(bufio.NewReader(r)),
+		checker: newSyntaxChecker(),
+	}
+}
+
+// Err returns the error that ended the iteration, if any.
+func (s *Stream) Err() error {
+	return s.err
+}
+
+// ArrayElements iterates over the elements of a top level array, decoding
+// one at a time. Reading stops at the first error, see Err.
+func (s *Stream) ArrayElements() iter.Seq2[int, *Value] {
+	return func(yield func(int, *Value) bool) {
+		if !s.open(LEFT_BRACKET, "array") {
+			return
+		}
+
+		for i := 0; ; {
+			t, ok := s.read()
+			if !ok {
+				return
+			}
+			switch t.TokenType {
+			case COMMA:
+				continue
+			case RIGHT_BRACKET:
+				s.close()
+				return
+			}
+
+			v, err := s.value(t)
+			if err != nil {
+				s.err = err
+				return
+			}
+			if !yield(i, v) {
+				return
+			}
+			i++
+		}
+	}
+}
+
+// ObjectMembers iterates over the members of a top level object, decoding
+// one value at a time. Reading stops at the first error, see Err.
+func (s *Stream) ObjectMembers() iter.Seq2[string, *Value] {
+	return func(yield func(string, *Value) bool) {
+		for m := range s.members() {
+			if !yield(m.Key, m.Value) {
+				return
+			}
+		}
+	}
+}
+
+// members iterates over the members of a top level object
+func (s *Stream) members() iter.Seq[Member] {
+	return func(yield func(Member) bool) {
+		if !s.open(LEFT_BRACE, "object") {
+			return
+		}
+
+		for {
+			t, ok := s.read()
+			if !ok {
+				return
+			}
+			switch t.TokenType {
+			case COMMA:
+				continue
+			case RIGHT_BRACE:
+				s.close()
+				return
+			}
+
+			m, err := s.member(t)
+			if err != nil {
+				s.err = err
+				return
+			}
+			if !yield(m) {
+				return
+			}
+		}
+	}
+}
+
+// open reads the opening token of the top level container
+func (s *Stream) open(want TokenType, name string) bool {
+	t, ok := s.read()
+	if ok && t.TokenType != want {
+		s.err = fmt.Errorf("expected %s at %v, got %s", name, t.Pos, describeToken(t))
+		return false
+	}
+	return ok
+}
+
+// close makes sure nothing follows the top level container
+func (s *Stream) close() {
+	s.read()
+}
+
+// read is next, recording the error
+func (s *Stream) read() (Token, bool) {
+	t, err := s.next()
+	if err != nil {
+		s.err = err
+		return Token{}, false
+	}
+	return t, true
+}
+
+// next reads a token and checks that it may follow the previous ones
+func (s *Stream) next() (Token, error) {
+	t, err := s.lexer.Next()
+	if err != nil {
+		return Token{}, err
+	}
+	if err := s.checker.next(t); err != nil {
+		return Token{}, err
+	}
+	return t, nil
+}
+
+// value decodes the value that starts with t, reading the rest of it
+func (s *Stream) value(t Token) (*Value, error) {
+	switch t.TokenType {
+	case LEFT_BRACKET:
+		v := &Value{Kind: ARRAY_VALUE, Pos: t.Pos, Elems: []*Value{}}
+		for {
+			t, err := s.next()
+			if err != nil {
+				return nil, err
+			}
+			switch t.TokenType {
+			case COMMA:
+				continue
+			case RIGHT_BRACKET:
+				return v, nil
+			}
+
+			elem, err := s.value(t)
+			if err != nil {
+				return nil, err
+			}
+			v.Elems = append(v.Elems, elem)
+		}
+	case LEFT_BRACE:
+		v := &Value{Kind: OBJECT_VALUE, Pos: t.Pos, Members: []Member{}}
+		for {
+			t, err := s.next()
+			if err != nil {
+				return nil, err
+			}
+			switch t.TokenType {
+			case COMMA:
+				continue
+			case RIGHT_BRACE:
+				return v, nil
+			}
+
+			m, err := s.member(t)
+			if err != nil {
+				return nil, err
+			}
+			v.Members = append(v.Members, m)
+		}
+	}
+	return scalarValue(t)
+}
+
+// member decodes an object member whose key token is t
+func (s *Stream) member(t Token) (Member, error) {
+	key, err := unquote(t.Value)
+	if err != nil {
+		return Member{}, fmt.Errorf("%v at %v", err, t.Pos)
+	}
+
+	// the colon, the checker already made sure it is there
+	if _, err := s.next(); err != nil {
+		return Member{}, err
+	}
+
+	first, err := s.next()
+	if err != nil {
+		return Member{}, err
+	}
+
+	v, err := s.value(first)
+	return Member{Key: key, KeyPos: t.Pos, Value: v}, err
+}
+
+// ArrayElements iterates over the elements of an array value, or nothing if
+// v is not an array.
+func (v *Value) ArrayElements() iter.Seq2[int, *Value] {
+	return func(yield func(int, *Value) bool) {
+		if v == nil || v.Kind != ARRAY_VALUE {
+			return
+		}
+		for i, e := range v.Elems {
+			if !yield(i, e) {
+				return
+			}
+		}
+	}
+}
+
+// ObjectMembers iterates over the members of an object value in document
+// order, or nothing if v is not an object.
+func (v *Value) ObjectMembers() iter.Seq2[string, *Value] {
+	return func(yield func(string, *Value) bool) {
+		if v == nil || v.Kind != OBJECT_VALUE {
+			return
+		}
+		for _, m := range v.Members {
+			if !yield(m.Key, m.Value) {
+				return
+			}
+		}
+	}
+}
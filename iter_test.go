@@ -0,0 +1,174 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestTokens(t *testing.T) {
+	var got []string
+	for tok, err := range Tokens(strings.NewReader(`{"a": [1, true]}`)) {
+		if err != nil {
+			t.Fatalf("error reading tokens %v", err)
+		}
+		got = append(got, tokenText(tok))
+	}
+
+	if s := strings.Join(got, " "); s != `{ "a" : [ 1 , true ] }` {
+		t.Errorf("unexpected tokens %s", s)
+	}
+}
+
+func TestTokensError(t *testing.T) {
+	n := 0
+	var last error
+	for _, err := range Tokens(strings.NewReader(`[1, 2,]`)) {
+		n++
+		last = err
+	}
+
+	if last == nil || n != 6 {
+		t.Errorf("expected error after 5 tokens, got %v after %d", last, n)
+	}
+}
+
+func TestStreamArrayElements(t *testing.T) {
+	s := NewStream(strings.NewReader(`[{"id": 1}, {"id": 2, "tags": ["a"]}, 3]`))
+
+	var got []string
+	for i, rec := range s.ArrayElements() {
+		if i != len(got) {
+			t.Errorf("unexpected index %d", i)
+		}
+		got = append(got, rec.String())
+	}
+	if err := s.Err(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if j := strings.Join(got, " "); j != `{"id":1} {"id":2,"tags":["a"]} 3` {
+		t.Errorf("unexpected elements %s", j)
+	}
+}
+
+func TestStreamArrayElementsBreak(t *testing.T) {
+	// stopping early leaves the rest of the input unread
+	s := NewStream(strings.NewReader(`[1, 2, oops`))
+
+	for i := range s.ArrayElements() {
+		if i == 1 {
+			break
+		}
+	}
+	if err := s.Err(); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestStreamErrors(t *testing.T) {
+	samples := []string{`{"a": 1}`, `[1, 2,]`, `[1] 2`, `[1, {"a" 1}]`, ``}
+
+	for _, sample := range samples {
+		s := NewStream(strings.NewReader(sample))
+		for range s.ArrayElements() {
+		}
+		if s.Err() == nil {
+			t.Errorf("%s: error should have been raised", sample)
+		}
+	}
+}
+
+func TestStreamObjectMembers(t *testing.T) {
+	s := NewStream(strings.NewReader(`{"a": 1, "b\n": {"c": [null]}, "a": "x"}`))
+
+	var got []string
+	for k, v := range s.ObjectMembers() {
+		got = append(got, k+"="+v.String())
+	}
+	if err := s.Err(); err != nil {
+		t.Fatalf("unexpected error %v", err)
+	}
+
+	if j := strings.Join(got, " "); j != "a=1 b\n={\"c\":[null]} a=\"x\"" {
+		t.Errorf("unexpected members %q", j)
+	}
+}
+
+func TestArrayElements(t *testing.T) {
+	var got []string
+	for rec, err := range ArrayElements(strings.NewReader(`[{"id": 1}, [2], 3]`)) {
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		got = append(got, rec.String())
+	}
+	if j := strings.Join(got, " "); j != `{"id":1} [2] 3` {
+		t.Errorf("unexpected elements %s", j)
+	}
+
+	n := 0
+	var last error
+	for rec, err := range ArrayElements(strings.NewReader(`[1, 2,]`)) {
+		n++
+		if err != nil && rec != nil {
+			t.Errorf("expected a nil value with the error, got %v", rec)
+		}
+		last = err
+	}
+	if last == nil || n != 3 {
+		t.Errorf("expected error after 2 elements, got %v after %d", last, n)
+	}
+
+	for range ArrayElements(strings.NewReader(`[1, 2, oops`)) {
+		break
+	}
+}
+
+func TestObjectMembers(t *testing.T) {
+	var got []string
+	for m, err := range ObjectMembers(strings.NewReader("{\"a\": 1,\n \"b\": [true]}")) {
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+		got = append(got, fmt.Sprintf("%s@%v=%s", m.Key, m.KeyPos, m.Value))
+	}
+	if j := strings.Join(got, " "); j != "a@1:2=1 b@2:2=[true]" {
+		t.Errorf("unexpected members %s", j)
+	}
+
+	var last error
+	for _, err := range ObjectMembers(strings.NewReader(`[1]`)) {
+		last = err
+	}
+	if last == nil {
+		t.Errorf("error should have been raised")
+	}
+}
+
+func TestValueIterators(t *testing.T) {
+	v, err := ParseValue([]byte(`{"list": [1, 2, 3], "obj": {"x": 1, "y": 2}}`))
+	if err != nil {
+		t.Fatalf("error parsing %v", err)
+	}
+
+	sum := 0
+	for i, e := range v.Get("list").ArrayElements() {
+		sum += i * int(e.Float())
+	}
+	if sum != 8 {
+		t.Errorf("unexpected sum %d", sum)
+	}
+
+	keys := ""
+	for k := range v.Get("obj").ObjectMembers() {
+		keys += k
+	}
+	if keys != "xy" {
+		t.Errorf("unexpected keys %s", keys)
+	}
+
+	for range v.Get("missing").ArrayElements() {
+		t.Errorf("expected no elements")
+	}
+}
@@ -3,6 +3,7 @@ package parser
 import (
 	"bufio"
 	"bytes"
+	"context"
 	"fmt"
 	"io"
 )
@@ -25,97 +26,169 @@ const (
 	SPACE
 )
 
-// how can you add row and col
+// Lexer reads tokens either from Reader or, when created with
+// NewLexerBytes, from an in-memory document.
 type Lexer struct {
 	Tokens []Token
 	Reader *bufio.Reader
+	pos    Position
+	// src is the document of an in-memory lexer, or the part of it that
+	// starts at offset base
+	src  string
+	base int
+	// short is set when the last error was caused by src ending in the
+	// middle of a token
+	short bool
+	// buf collects the bytes of a number read from Reader
+	buf bytes.Buffer
 }
 
 type Token struct {
 	TokenType TokenType
 	Value     string
+	Pos       Position
+}
+
+// Position is the location of a token in the input. Line and Col are
+// 1-based, Col and Offset are counted in bytes.
+type Position struct {
+	Offset int
+	Line   int
+	Col    int
+}
+
+func (p Position) String() string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Col)
 }
 
 func NewLexer(rd *bufio.Reader) *Lexer {
 	return &Lexer{
 		Reader: rd,
 		Tokens: []Token{},
+		pos:    Position{Line: 1, Col: 1},
 	}
 }
 
 // let's just assume it's an array of bytes
 
 func (r *Lexer) Tokenize() ([]Token, error) {
+	return r.TokenizeContext(context.Background())
+}
+
+// TokenizeContext is Tokenize, giving up with a ContextError once ctx is
+// done. A Read that blocks is not interrupted, the context is looked at
+// between tokens.
+func (r *Lexer) TokenizeContext(ctx context.Context) ([]Token, error) {
+	if cap(r.Tokens) == 0 && len(r.src) > 0 {
+		// a rough guess of the token count saves most of the regrowing
+		r.Tokens = make([]Token, 0, len(r.src)/8+8)
+	}
+
+	for n := 1; ; n++ {
+		if n%checkEvery == 0 {
+			if err := checkContext(ctx, r.pos.Offset); err != nil {
+				return nil, err
+			}
+		}
+
+		t, err := r.Next()
+		if err != nil {
+			return nil, err
+		}
+		r.Tokens = append(r.Tokens, t)
+		if t.TokenType == EOF {
+			return r.Tokens, nil
+		}
+	}
+}
+
+// Next reads a single token from the reader, skipping whitespace before it.
+// At the end of the input it returns an EOF token. Unlike Tokenize it does
+// not keep the tokens, so it can be used to stream through large inputs.
+func (r *Lexer) Next() (Token, error) {
+	if r.Reader == nil {
+		return r.nextBytes()
+	}
+
 	for {
 		cur, err := r.Reader.ReadByte()
 		if err != nil {
 			if err == io.EOF {
-				r.Tokens = append(r.Tokens, Token{TokenType: EOF})
-				return r.Tokens, nil
+				return Token{TokenType: EOF, Pos: r.pos}, nil
 			}
-			return nil, err
+			return Token{}, err
 		}
 
+		var t *Token
+
 		switch cur {
-		case ' ', '\n', '\r':
+		case ' ':
+			// skip the rest of a run of spaces in one go
+			buf, _ := r.Reader.Peek(r.Reader.Buffered())
+			n, _ := r.Reader.Discard(spanSpaces(buf))
+			r.pos.Offset += n + 1
+			r.pos.Col += n + 1
+			continue
+		case '\r', '\t':
 			// Skip whitespace
+			r.pos.Offset++
+			r.pos.Col++
+			continue
+		case '\n':
+			r.pos.Offset++
+			r.pos.Line++
+			r.pos.Col = 1
 			continue
 		case '{':
-			r.Tokens = append(r.Tokens, Token{TokenType: LEFT_BRACE, Value: "{"})
+			t = &Token{TokenType: LEFT_BRACE, Value: "{"}
 		case '}':
-			r.Tokens = append(r.Tokens, Token{TokenType: RIGHT_BRACE, Value: "}"})
+			t = &Token{TokenType: RIGHT_BRACE, Value: "}"}
 		case '[':
-			r.Tokens = append(r.Tokens, Token{TokenType: LEFT_BRACKET, Value: "["})
+			t = &Token{TokenType: LEFT_BRACKET, Value: "["}
 		case ']':
-			r.Tokens = append(r.Tokens, Token{TokenType: RIGHT_BRACKET, Value: "]"})
+			t = &Token{TokenType: RIGHT_BRACKET, Value: "]"}
 		case ':':
-			r.Tokens = append(r.Tokens, Token{TokenType: COLON, Value: ":"})
+			t = &Token{TokenType: COLON, Value: ":"}
 		case ',':
-			r.Tokens = append(r.Tokens, Token{TokenType: COMMA, Value: ","})
+			t = &Token{TokenType: COMMA, Value: ","}
 		case '"':
 			r.Reader.UnreadByte()
-			t, err := r.tokenizeString()
-			if err != nil {
-				return nil, err
-			}
-			r.Tokens = append(r.Tokens, *t)
-		case 'f':
+			t, err = r.tokenizeString()
+		case 'f', 't':
 			r.Reader.UnreadByte()
-			t, err := r.tokenizeBool(r.Reader)
-			if err != nil {
-				return nil, err
-			}
-			r.Tokens = append(r.Tokens, *t)
-		case 't':
-			r.Reader.UnreadByte()
-			t, err := r.tokenizeBool(r.Reader)
-			if err != nil {
-				return nil, err
-			}
-			r.Tokens = append(r.Tokens, *t)
-
+			t, err = r.tokenizeBool(r.Reader)
 		case 'n':
 			r.Reader.UnreadByte()
-			t, err := r.tokenizeNull(r.Reader)
-			if err != nil {
-				return nil, err
-			}
-			r.Tokens = append(r.Tokens, *t)
+			t, err = r.tokenizeNull(r.Reader)
 		case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
 			r.Reader.UnreadByte()
-			t, err := r.tokenizeNumber()
-			if err != nil {
-				return nil, err
-			}
-			r.Tokens = append(r.Tokens, *t)
-		case '\t':
-			return nil, fmt.Errorf("incorrect json structure: tab charater")
+			t, err = r.tokenizeNumber()
 		default:
-			return nil, fmt.Errorf("unexpected character: %c", cur)
+			return Token{}, fmt.Errorf("unexpected character: %c", cur)
 		}
+
+		if err != nil {
+			return Token{}, err
+		}
+		return r.advance(*t), nil
 	}
 }
 
+// advance records the current position on the token and moves the position
+// past the bytes it was read from
+func (r *Lexer) advance(t Token) Token {
+	t.Pos = r.pos
+
+	n := len(t.Value)
+	if t.TokenType == STRING {
+		n += 2 // quotes are not part of the value
+	}
+	r.pos.Offset += n
+	r.pos.Col += n
+
+	return t
+}
+
 // escape character only allowed for ", \, /, b, f, r, t, u
 func (r *Lexer) handleEscapeCharacters() error {
 	_, err := r.Reader.ReadByte()
@@ -133,38 +206,19 @@ func (r *Lexer) handleEscapeCharacters() error {
 }
 
 func (r *Lexer) tokenizeBool(rd *bufio.Reader) (*Token, error) {
-	//look further 5 bytes false
-	n, err := rd.Peek(5)
-	if err != nil {
-		return nil, err
-	}
-	ok := bytes.Equal(n, []byte("false"))
-	if !ok {
-		n, err = rd.Peek(4)
-		if err != nil {
-			return nil, err
-		}
-	}
-	ok1 := bytes.Equal(n, []byte("true"))
-	if !ok && !ok1 {
-		return nil, fmt.Errorf("error parsing bool")
-	}
-	re := &Token{}
-	if ok {
-		re.TokenType = FALSE
-		re.Value = "false"
-	} else {
-		re.TokenType = TRUE
-		re.Value = "true"
+	// a short peek at the end of the input is not an error, it just
+	// won't match
+	if n, _ := rd.Peek(5); bytes.Equal(n, []byte("false")) {
+		rd.Discard(5)
+		return &Token{TokenType: FALSE, Value: "false"}, nil
 	}
 
-	if ok {
-		rd.Discard(5)
-	} else {
+	if n, _ := rd.Peek(4); bytes.Equal(n, []byte("true")) {
 		rd.Discard(4)
+		return &Token{TokenType: TRUE, Value: "true"}, nil
 	}
 
-	return re, nil
+	return nil, fmt.Errorf("error parsing bool")
 }
 
 func (r *Lexer) tokenizeString() (*Token, error) {
@@ -180,13 +234,19 @@ func (r *Lexer) tokenizeString() (*Token, error) {
 
 	if bytes.Equal(two, []byte{'"', '"'}) {
 		rd.Discard(2)
-		val = append(val, '"', '"')
-		return &Token{TokenType: STRING, Value: string(val)}, nil
+		return &Token{TokenType: STRING, Value: ""}, nil
 	}
 
 	rd.ReadByte() // Consume opening quote
 
 	for {
+		// copy everything up to the next quote, backslash or control byte
+		if buf, _ := rd.Peek(rd.Buffered()); len(buf) > 0 {
+			n := spanPlain(buf)
+			val = append(val, buf[:n]...)
+			rd.Discard(n)
+		}
+
 		cur, err := rd.ReadByte()
 		if err != nil {
 			return nil, fmt.Errorf("error while parsing string token: %v", err)
@@ -202,7 +262,7 @@ func (r *Lexer) tokenizeString() (*Token, error) {
 				return nil, fmt.Errorf("error while parsing escape sequence: %v", err)
 			}
 			switch next {
-			case '\\', '/', 'b', 'f', 'n', 'r':
+			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
 				val = append(val, '\\', next)
 			case 'u':
 				unicodeSeq := make([]byte, 4)
@@ -210,6 +270,9 @@ func (r *Lexer) tokenizeString() (*Token, error) {
 				if err != nil {
 					return nil, fmt.Errorf("error while parsing unicode sequence: %v", err)
 				}
+				if _, ok := hex4(string(unicodeSeq)); !ok {
+					return nil, fmt.Errorf("invalid unicode escape: \\u%s", unicodeSeq)
+				}
 				val = append(val, '\\', 'u')
 				val = append(val, unicodeSeq...)
 			default:
@@ -217,7 +280,7 @@ func (r *Lexer) tokenizeString() (*Token, error) {
 			}
 		} else if cur == '\t' {
 			return nil, fmt.Errorf("tab character")
-		} else if cur < 32 || cur > 126 {
+		} else if cur < 32 {
 			return nil, fmt.Errorf("non-printable character")
 		} else {
 			val = append(val, cur)
@@ -227,57 +290,74 @@ func (r *Lexer) tokenizeString() (*Token, error) {
 	return &Token{TokenType: STRING, Value: string(val)}, nil
 }
 
+// tokenizeNumber reads -?(0|[1-9][0-9]*)(\.[0-9]+)?([eE][+-]?[0-9]+)?
 func (r *Lexer) tokenizeNumber() (*Token, error) {
-	var buf bytes.Buffer
-	var hasDecimal, hasExponent bool
+	buf := &r.buf
+	buf.Reset()
 
-	// Read the first character (- or digit)
-	first, _ := r.Reader.ReadByte()
-
-	n, _ := r.Reader.Peek(1)
-
-	if first == '0' && isValidNumberByte(n[0]) {
-		return nil, fmt.Errorf("cannot have leading zeros")
+	if r.peekByte() == '-' {
+		buf.WriteByte(r.readByte())
 	}
 
-	buf.WriteByte(first)
+	switch first := r.peekByte(); {
+	case first == '0':
+		buf.WriteByte(r.readByte())
+		if isValidNumberByte(r.peekByte()) {
+			return nil, fmt.Errorf("cannot have leading zeros")
+		}
+	case isValidNumberByte(first):
+		r.readDigits(buf)
+	default:
+		return nil, fmt.Errorf("invalid number %s: expected digit", buf.String())
+	}
 
-	for {
-		next, err := r.Reader.ReadByte()
-		if err == io.EOF {
-			break
+	if r.peekByte() == '.' {
+		buf.WriteByte(r.readByte())
+		if r.readDigits(buf) == 0 {
+			return nil, fmt.Errorf("invalid number %s: expected digit after decimal point", buf.String())
 		}
+	}
 
-		switch {
-		case next >= '0' && next <= '9':
-			buf.WriteByte(next)
-		case next == '.' && !hasDecimal && !hasExponent:
-			hasDecimal = true
-			buf.WriteByte(next)
-		case (next == 'e' || next == 'E') && !hasExponent:
-			hasExponent = true
-			buf.WriteByte(next)
-			// Check for + or - after E
-			expSign, err := r.Reader.ReadByte()
-			if err == nil && (expSign == '+' || expSign == '-') {
-				buf.WriteByte(expSign)
-			} else if err == nil {
-				r.Reader.UnreadByte()
-			}
-		default:
-			r.Reader.UnreadByte()
-			return &Token{TokenType: NUMBER, Value: buf.String()}, nil
+	if next := r.peekByte(); next == 'e' || next == 'E' {
+		buf.WriteByte(r.readByte())
+		// Check for + or - after E
+		if sign := r.peekByte(); sign == '+' || sign == '-' {
+			buf.WriteByte(r.readByte())
+		}
+		if r.readDigits(buf) == 0 {
+			return nil, fmt.Errorf("invalid number %s: expected digit in exponent", buf.String())
 		}
 	}
 
 	return &Token{TokenType: NUMBER, Value: buf.String()}, nil
 }
 
-func (r *Lexer) tokenizeNull(rd *bufio.Reader) (*Token, error) {
-	n, err := rd.Peek(4)
+// peekByte returns the next byte without consuming it, or 0 at the end of
+// the input
+func (r *Lexer) peekByte() byte {
+	n, err := r.Reader.Peek(1)
 	if err != nil {
-		return nil, err
+		return 0
+	}
+	return n[0]
+}
+
+func (r *Lexer) readByte() byte {
+	b, _ := r.Reader.ReadByte()
+	return b
+}
+
+func (r *Lexer) readDigits(buf *bytes.Buffer) int {
+	n := 0
+	for isValidNumberByte(r.peekByte()) {
+		buf.WriteByte(r.readByte())
+		n++
 	}
+	return n
+}
+
+func (r *Lexer) tokenizeNull(rd *bufio.Reader) (*Token, error) {
+	n, _ := rd.Peek(4)
 	ok := bytes.Equal(n, []byte("null"))
 	if !ok {
 		return nil, fmt.Errorf("error parsing null")
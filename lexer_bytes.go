@@ -0,0 +1,213 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// NewLexerBytes returns a lexer over an in-memory document. The input is
+// copied once into a string and every token Value is a substring of it, so
+// reading a token does not allocate.
+func NewLexerBytes(input []byte) *Lexer {
+	return &Lexer{
+		Tokens: []Token{},
+		src:    string(input),
+		pos:    Position{Line: 1, Col: 1},
+	}
+}
+
+// Reset makes r read input from the start, reusing its token slice. input
+// is copied into a new string, as strings handed out for the previous
+// document point into the old one and must not change.
+func (r *Lexer) Reset(input []byte) {
+	r.src = string(input)
+	r.Reader = nil
+	r.Tokens = r.Tokens[:0]
+	r.pos = Position{Line: 1, Col: 1}
+	r.base = 0
+	r.short = false
+	r.buf.Reset()
+}
+
+// nextBytes is Next for lexers created by NewLexerBytes
+func (r *Lexer) nextBytes() (Token, error) {
+	src := r.src
+	i := r.pos.Offset - r.base
+	r.short = false
+
+	for ; i < len(src); i++ {
+		c := src[i]
+		if c == ' ' {
+			n := spanSpaces(src[i:])
+			r.pos.Col += n
+			i += n - 1
+			continue
+		}
+		if c == '\n' {
+			r.pos.Line++
+			r.pos.Col = 1
+			continue
+		}
+		if c != ' ' && c != '\r' && c != '\t' {
+			break
+		}
+		r.pos.Col++
+	}
+	r.pos.Offset = r.base + i
+
+	if i == len(src) {
+		return Token{TokenType: EOF, Pos: r.pos}, nil
+	}
+
+	t := Token{Pos: r.pos}
+	end := i + 1
+
+	switch c := src[i]; c {
+	case '{':
+		t.TokenType = LEFT_BRACE
+	case '}':
+		t.TokenType = RIGHT_BRACE
+	case '[':
+		t.TokenType = LEFT_BRACKET
+	case ']':
+		t.TokenType = RIGHT_BRACKET
+	case ':':
+		t.TokenType = COLON
+	case ',':
+		t.TokenType = COMMA
+	case '"':
+		n, err := scanString(src[i:])
+		if err != nil {
+			r.short = i+n == len(src)
+			return Token{}, err
+		}
+		end = i + n
+		t.TokenType = STRING
+		// quotes are not part of the value
+		t.Value = src[i+1 : end-1]
+	case 'f', 't':
+		switch {
+		case strings.HasPrefix(src[i:], "false"):
+			t.TokenType, end = FALSE, i+5
+		case strings.HasPrefix(src[i:], "true"):
+			t.TokenType, end = TRUE, i+4
+		default:
+			r.short = strings.HasPrefix("false", src[i:]) || strings.HasPrefix("true", src[i:])
+			return Token{}, fmt.Errorf("error parsing bool")
+		}
+	case 'n':
+		if !strings.HasPrefix(src[i:], "null") {
+			r.short = strings.HasPrefix("null", src[i:])
+			return Token{}, fmt.Errorf("error parsing null")
+		}
+		t.TokenType, end = NULL, i+4
+	case '-', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		n, err := scanNumber(src[i:])
+		if err != nil {
+			r.short = i+n == len(src)
+			return Token{}, err
+		}
+		t.TokenType, end = NUMBER, i+n
+	default:
+		return Token{}, fmt.Errorf("unexpected character: %c", c)
+	}
+
+	if t.TokenType != STRING {
+		t.Value = src[i:end]
+	}
+	r.pos.Col += end - i
+	r.pos.Offset = r.base + end
+
+	return t, nil
+}
+
+// scanString returns the length of the string literal at the start of s,
+// quotes included, applying the same checks as tokenizeString. On error it
+// returns where the problem was found.
+func scanString(s string) (int, error) {
+	for i := 1; i < len(s); i++ {
+		if i += spanPlain(s[i:]); i == len(s) {
+			break
+		}
+
+		switch c := s[i]; {
+		case c == '"':
+			return i + 1, nil
+		case c == '\\':
+			if i+1 == len(s) {
+				return len(s), fmt.Errorf("error while parsing escape sequence: %v", io.EOF)
+			}
+			i++
+			switch s[i] {
+			case '"', '\\', '/', 'b', 'f', 'n', 'r', 't':
+			case 'u':
+				if len(s) < i+5 {
+					return len(s), fmt.Errorf("error while parsing unicode sequence: %v", io.ErrUnexpectedEOF)
+				}
+				if _, ok := hex4(s[i+1 : i+5]); !ok {
+					return i, fmt.Errorf("invalid unicode escape: \\u%s", s[i+1:i+5])
+				}
+				i += 4
+			default:
+				return i, fmt.Errorf("invalid escape sequence: \\%c", s[i])
+			}
+		case c == '\t':
+			return i, fmt.Errorf("tab character")
+		case c < 32:
+			return i, fmt.Errorf("non-printable character")
+		}
+	}
+	return len(s), fmt.Errorf("error while parsing string token: %v", io.EOF)
+}
+
+// scanNumber returns the length of the number literal at the start of s,
+// see tokenizeNumber for the grammar. On error it returns where the problem
+// was found.
+func scanNumber(s string) (int, error) {
+	i := 0
+	if s[i] == '-' {
+		i++
+	}
+
+	switch {
+	case i < len(s) && s[i] == '0':
+		i++
+		if i < len(s) && isValidNumberByte(s[i]) {
+			return i, fmt.Errorf("cannot have leading zeros")
+		}
+	case i < len(s) && isValidNumberByte(s[i]):
+		i = scanDigits(s, i)
+	default:
+		return i, fmt.Errorf("invalid number %s: expected digit", s[:i])
+	}
+
+	if i < len(s) && s[i] == '.' {
+		i++
+		start := i
+		if i = scanDigits(s, i); i == start {
+			return i, fmt.Errorf("invalid number %s: expected digit after decimal point", s[:i])
+		}
+	}
+
+	if i < len(s) && (s[i] == 'e' || s[i] == 'E') {
+		i++
+		// Check for + or - after E
+		if i < len(s) && (s[i] == '+' || s[i] == '-') {
+			i++
+		}
+		start := i
+		if i = scanDigits(s, i); i == start {
+			return i, fmt.Errorf("invalid number %s: expected digit in exponent", s[:i])
+		}
+	}
+
+	return i, nil
+}
+
+func scanDigits(s string, i int) int {
+	for i < len(s) && isValidNumberByte(s[i]) {
+		i++
+	}
+	return i
+}
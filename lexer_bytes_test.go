@@ -0,0 +1,107 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestLexerBytesMatchesReader(t *testing.T) {
+	files, _ := filepath.Glob(filepath.Join(conformanceDir, "*.json"))
+	fixtures, _ := filepath.Glob("./main/test*/*.json")
+
+	for _, file := range append(files, fixtures...) {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("error reading %s: %v", file, err)
+		}
+
+		fromReader, errReader := NewLexer(bufio.NewReader(bytes.NewReader(content))).Tokenize()
+		fromBytes, errBytes := NewLexerBytes(content).Tokenize()
+		if (errReader == nil) != (errBytes == nil) {
+			t.Errorf("%s: reader error %v, bytes error %v", file, errReader, errBytes)
+			continue
+		}
+		if errReader == nil && !reflect.DeepEqual(fromReader, fromBytes) {
+			t.Errorf("%s: tokens differ\n%v\n%v", file, fromReader, fromBytes)
+		}
+	}
+}
+
+func TestLexerBytesPositions(t *testing.T) {
+	tokens, err := NewLexerBytes([]byte("{\n\t\"a\\n\": [-1.5e3, true]\r\n}")).Tokenize()
+	if err != nil {
+		t.Fatalf("error tokenizing %v", err)
+	}
+
+	want := []Token{
+		{LEFT_BRACE, "{", Position{0, 1, 1}},
+		{STRING, `a\n`, Position{3, 2, 2}},
+		{COLON, ":", Position{8, 2, 7}},
+		{LEFT_BRACKET, "[", Position{10, 2, 9}},
+		{NUMBER, "-1.5e3", Position{11, 2, 10}},
+		{COMMA, ",", Position{17, 2, 16}},
+		{TRUE, "true", Position{19, 2, 18}},
+		{RIGHT_BRACKET, "]", Position{23, 2, 22}},
+		{RIGHT_BRACE, "}", Position{26, 3, 1}},
+		{EOF, "", Position{27, 3, 2}},
+	}
+	if !reflect.DeepEqual(tokens, want) {
+		t.Errorf("unexpected tokens\n%v\n%v", tokens, want)
+	}
+}
+
+func TestLexerBytesAllocations(t *testing.T) {
+	input := []byte(`{"id": 12, "tags": ["a", "b\"c"], "ok": true, "n": null, "x": -0.5e10}`)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		l := NewLexerBytes(input)
+		for {
+			tok, err := l.Next()
+			if err != nil {
+				t.Fatalf("error tokenizing %v", err)
+			}
+			if tok.TokenType == EOF {
+				break
+			}
+		}
+	})
+
+	// the lexer, its token slice and the copy of the input, none per token
+	if allocs > 3 {
+		t.Errorf("expected at most 3 allocations, got %v", allocs)
+	}
+}
+
+func TestLexerReset(t *testing.T) {
+	l := NewLexerBytes([]byte(`{"a": [1, 2]}`))
+	if _, err := l.Tokenize(); err != nil {
+		t.Fatalf("error tokenizing %v", err)
+	}
+
+	input := []byte(`["x",` + "\n" + ` true]`)
+	l.Reset(input)
+	got, err := l.Tokenize()
+	if err != nil {
+		t.Fatalf("error tokenizing %v", err)
+	}
+
+	want, _ := NewLexerBytes(input).Tokenize()
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+
+	// a second reset reuses the token slice, only the input is copied
+	allocs := testing.AllocsPerRun(100, func() {
+		l.Reset(input)
+		if _, err := l.Tokenize(); err != nil {
+			t.Fatalf("error tokenizing %v", err)
+		}
+	})
+	if allocs != 1 {
+		t.Errorf("expected a single allocation, got %v", allocs)
+	}
+}
@@ -110,3 +110,29 @@ func TestJohn4(t *testing.T) {
 
 	t.Log(p)
 }
+
+func TestTokenPositions(t *testing.T) {
+	sample := []byte("{\n  \"key\": [1, \"\"],\n  \"b\": null\n}")
+
+	rd := bufio.NewReader(bytes.NewReader(sample))
+
+	p, err := NewLexer(rd).Tokenize()
+	if err != nil {
+		t.Fatalf("error parsing %v", err)
+	}
+
+	want := []Position{
+		{0, 1, 1}, {4, 2, 3}, {9, 2, 8}, {11, 2, 10}, {12, 2, 11}, {13, 2, 12}, {15, 2, 14},
+		{17, 2, 16}, {18, 2, 17}, {22, 3, 3}, {25, 3, 6}, {27, 3, 8}, {32, 4, 1}, {33, 4, 2},
+	}
+
+	if len(p) != len(want) {
+		t.Fatalf("expected %d tokens, got %d: %v", len(want), len(p), p)
+	}
+
+	for i, tok := range p {
+		if tok.Pos != want[i] {
+			t.Errorf("token %d (%s): expected position %+v, got %+v", i, tok.Value, want[i], tok.Pos)
+		}
+	}
+}
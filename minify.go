@@ -0,0 +1,58 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+)
+
+// Minify copies the JSON document read from src to dst without whitespace.
+// Tokens are checked and written as they are read, so memory use does not
+// depend on the size of the document, but on invalid input dst may already
+// hold part of the output when the error is returned.
+func Minify(dst io.Writer, src io.Reader) error {
+	w := bufio.NewWriter(dst)
+
+	err := minify(w, src)
+	if ferr := w.Flush(); err == nil {
+		err = ferr
+	}
+	return err
+}
+
+// MinifyBuffered is like Minify but holds the output back until the whole
+// document has been validated, so nothing is written to dst on error.
+func MinifyBuffered(dst io.Writer, src io.Reader) error {
+	var buf bytes.Buffer
+
+	if err := minify(&buf, src); err != nil {
+		return err
+	}
+
+	_, err := buf.WriteTo(dst)
+	return err
+}
+
+func minify(w io.Writer, src io.Reader) error {
+	lexer := NewLexer(bufio.NewReader(src))
+	checker := newSyntaxChecker()
+
+	for {
+		t, err := lexer.Next()
+		if err != nil {
+			return err
+		}
+
+		if err := checker.next(t); err != nil {
+			return err
+		}
+
+		if t.TokenType == EOF {
+			return nil
+		}
+
+		if _, err := io.WriteString(w, tokenText(t)); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package parser
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestMinify(t *testing.T) {
+	sample := "{\n  \"a\" : [ 1, 2.5e3 , \"x y\" ],\r\n  \"b\": { \"c\": null, \"d\": true },\n  \"e\": []\n}\n"
+
+	var out bytes.Buffer
+	if err := Minify(&out, strings.NewReader(sample)); err != nil {
+		t.Fatalf("error minifying %v", err)
+	}
+
+	want := `{"a":[1,2.5e3,"x y"],"b":{"c":null,"d":true},"e":[]}`
+	if out.String() != want {
+		t.Errorf("expected %s, got %s", want, out.String())
+	}
+}
+
+func TestMinifyInvalid(t *testing.T) {
+	samples := []string{
+		`{"a": [1, 2,]}`,
+		`{"a" 1}`,
+		`[1] [2]`,
+		`{"a": 1`,
+		`["a", }`,
+		``,
+	}
+
+	for _, s := range samples {
+		var out bytes.Buffer
+		if err := MinifyBuffered(&out, strings.NewReader(s)); err == nil {
+			t.Errorf("%s: error should have been raised", s)
+		}
+		if out.Len() != 0 {
+			t.Errorf("%s: buffered mode wrote %q", s, out.String())
+		}
+	}
+}
+
+func TestMinifyStreamingWritesPartialOutput(t *testing.T) {
+	var out bytes.Buffer
+
+	err := Minify(&out, strings.NewReader(`[1, 2, 3 4]`))
+	if err == nil {
+		t.Fatalf("error should have been raised")
+	}
+
+	if out.String() != "[1,2,3" {
+		t.Errorf("unexpected partial output %q", out.String())
+	}
+}
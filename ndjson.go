@@ -0,0 +1,227 @@
+package parser
+
+import (
+	"bytes"
+	"io"
+	"iter"
+	"runtime"
+	"strings"
+	"sync"
+	"unsafe"
+)
+
+// linesChunk is how much of a JSON Lines document a worker gets at once.
+// Chunks end at a newline, so a chunk holding a longer line grows to fit it.
+var linesChunk = 1 << 20
+
+// Record is a single line of a JSON Lines (NDJSON) document.
+type Record struct {
+	// Line is the 1-based line number of the record
+	Line  int
+	Value *Value
+	Err   error
+}
+
+// DecodeLines decodes a JSON Lines document read from r on up to workers
+// goroutines, or one per CPU if workers is 0. The input is split into
+// chunks at newlines and every chunk is lexed, checked and decoded by a
+// single worker with its own lexer, so nothing is shared between them.
+// Records are yielded in input order and positions in errors are those of
+// the whole document. Blank lines are skipped. A read error is yielded as
+// a record after the last complete one and ends the sequence.
+//
+// Stopping the loop early stops the workers.
+func DecodeLines(r io.Reader, workers int) iter.Seq[Record] {
+	return lines(r, workers, true)
+}
+
+// ValidateLines is like DecodeLines but only checks the records and yields
+// the invalid ones.
+func ValidateLines(r io.Reader, workers int) iter.Seq[Record] {
+	return lines(r, workers, false)
+}
+
+// chunk is a run of complete lines of a JSON Lines document
+type chunk struct {
+	seq  int
+	src  []byte
+	pos  Position
+	recs []Record
+	err  error
+}
+
+func lines(r io.Reader, workers int, decode bool) iter.Seq[Record] {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	return func(yield func(Record) bool) {
+		todo := make(chan *chunk)
+		done := make(chan *chunk, workers)
+		stop := make(chan struct{})
+		defer close(stop)
+
+		// chunks that are being worked on or wait for their turn to be
+		// yielded, this keeps memory bounded when the consumer is slow
+		slots := make(chan struct{}, 2*workers)
+
+		go readChunks(r, linesChunk, todo, slots, stop)
+
+		var wg sync.WaitGroup
+		for w := 0; w < workers; w++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for c := range todo {
+					c.check(decode)
+					select {
+					case done <- c:
+					case <-stop:
+						return
+					}
+				}
+			}()
+		}
+		go func() {
+			wg.Wait()
+			close(done)
+		}()
+
+		pending := map[int]*chunk{}
+		want := 0
+		for c := range done {
+			pending[c.seq] = c
+			for c := pending[want]; c != nil; c = pending[want] {
+				delete(pending, want)
+				want++
+				<-slots
+
+				for _, rec := range c.recs {
+					if (decode || rec.Err != nil) && !yield(rec) {
+						return
+					}
+				}
+				if c.err != nil {
+					yield(Record{Line: c.pos.Line + bytes.Count(c.src, []byte{'\n'}), Err: c.err})
+					return
+				}
+			}
+		}
+	}
+}
+
+// readChunks splits the document into chunks and sends them to todo. The
+// last chunk carries the read error, if any.
+func readChunks(r io.Reader, size int, todo chan<- *chunk, slots chan struct{}, stop <-chan struct{}) {
+	defer close(todo)
+
+	pos := Position{Line: 1, Col: 1}
+	var rest []byte
+	for seq := 0; ; seq++ {
+		// every chunk gets a buffer of its own, so workers can keep
+		// pointing into it after it is handed off
+		buf := make([]byte, len(rest), max(size, 2*len(rest)))
+		copy(buf, rest)
+
+		n, err := io.ReadFull(r, buf[len(buf):cap(buf)])
+		buf = buf[:len(buf)+n]
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			err = io.EOF
+		}
+
+		c := &chunk{seq: seq, src: buf, pos: pos}
+		rest = nil
+		if err != io.EOF {
+			// keep the incomplete last line for the next chunk
+			end := bytes.LastIndexByte(buf, '\n') + 1
+			c.src, rest = buf[:end], buf[end:]
+			c.err = err
+		}
+
+		select {
+		case slots <- struct{}{}:
+		case <-stop:
+			return
+		}
+		select {
+		case todo <- c:
+		case <-stop:
+			return
+		}
+
+		if err != nil {
+			return
+		}
+		pos.Offset += len(c.src)
+		pos.Line += bytes.Count(c.src, []byte{'\n'})
+	}
+}
+
+// check validates, and if decode is set decodes, every line of c
+func (c *chunk) check(decode bool) {
+	// buffers are never written to after they are read, so tokens and
+	// decoded strings can point into them
+	src := unsafe.String(unsafe.SliceData(c.src), len(c.src))
+
+	lc := lineChecker{checker: checkerPool.Get().(*syntaxChecker), decode: decode}
+	defer checkerPool.Put(lc.checker)
+
+	pos := c.pos
+	for len(src) > 0 {
+		line, next := src, ""
+		if i := strings.IndexByte(src, '\n'); i >= 0 {
+			line, next = src[:i], src[i+1:]
+		}
+
+		if rec, blank := lc.check(line, pos); !blank {
+			c.recs = append(c.recs, rec)
+		}
+
+		pos.Offset += len(src) - len(next)
+		pos.Line++
+		src = next
+	}
+}
+
+// lineChecker checks the records of a chunk, reusing its token slice
+type lineChecker struct {
+	checker *syntaxChecker
+	tokens  []Token
+	decode  bool
+}
+
+// check checks a single record, without its newline, that starts at pos.
+// It reports whether the line holds only whitespace.
+func (lc *lineChecker) check(line string, pos Position) (Record, bool) {
+	rec := Record{Line: pos.Line}
+	lexer := Lexer{src: line, base: pos.Offset, pos: pos}
+	lc.checker.reset()
+	lc.tokens = lc.tokens[:0]
+
+	for first := true; ; first = false {
+		t, err := lexer.nextBytes()
+		if err == nil {
+			if first && t.TokenType == EOF {
+				return rec, true
+			}
+			err = lc.checker.next(t)
+		}
+		if err != nil {
+			rec.Err = err
+			return rec, false
+		}
+
+		if lc.decode {
+			lc.tokens = append(lc.tokens, t)
+		}
+		if t.TokenType == EOF {
+			break
+		}
+	}
+
+	if lc.decode {
+		idx := 0
+		rec.Value, rec.Err = buildValue(lc.tokens, &idx)
+	}
+	return rec, false
+}
@@ -0,0 +1,170 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+// sampleLines builds a JSON Lines document with some blank and invalid
+// lines. It returns the document and, for every record, its line number
+// and whether it is valid.
+func sampleLines(n int) (string, []int, []bool) {
+	var sb strings.Builder
+	var lines []int
+	var valid []bool
+
+	line := 1
+	for i := 0; i < n; i++ {
+		switch {
+		case i%7 == 3:
+			sb.WriteString("\n  \r\n")
+			line += 2
+		case i%11 == 5:
+			fmt.Fprintf(&sb, "{\"id\": %d, \"tags\": [\"a\",]}\n", i)
+			lines, valid = append(lines, line), append(valid, false)
+			line++
+			continue
+		}
+		fmt.Fprintf(&sb, "{\"id\": %d, \"name\": \"record %d\", \"tags\": [\"a\", \"b\"]}\r\n", i, i)
+		lines, valid = append(lines, line), append(valid, true)
+		line++
+	}
+	return sb.String(), lines, valid
+}
+
+func TestDecodeLines(t *testing.T) {
+	chunk := linesChunk
+	linesChunk = 64
+	defer func() { linesChunk = chunk }()
+
+	doc, lines, valid := sampleLines(500)
+	docLines := strings.Split(doc, "\n")
+
+	i := 0
+	for rec := range DecodeLines(strings.NewReader(doc), 4) {
+		if i == len(lines) {
+			t.Fatalf("unexpected record %+v", rec)
+		}
+		if rec.Line != lines[i] {
+			t.Errorf("record %d: expected line %d, got %d", i, lines[i], rec.Line)
+		}
+
+		want, err := ParseValue([]byte(docLines[rec.Line-1]))
+		if (rec.Err == nil) != valid[i] || (err == nil) != valid[i] {
+			t.Errorf("line %d: unexpected error %v", rec.Line, rec.Err)
+		}
+		if rec.Err != nil && !strings.Contains(rec.Err.Error(), fmt.Sprintf(" %d:", rec.Line)) {
+			t.Errorf("line %d: error does not carry the line number: %v", rec.Line, rec.Err)
+		}
+		if rec.Err == nil && rec.Value.String() != want.String() {
+			t.Errorf("line %d: expected %v, got %v", rec.Line, want, rec.Value)
+		}
+		i++
+	}
+	if i != len(lines) {
+		t.Errorf("expected %d records, got %d", len(lines), i)
+	}
+}
+
+func TestDecodeLinesPositions(t *testing.T) {
+	doc := "{\"a\": 1}\n[1, 2, \"x\"]\n"
+
+	var got []Position
+	for rec := range DecodeLines(strings.NewReader(doc), 2) {
+		got = append(got, rec.Value.Pos)
+	}
+	want := []Position{{Offset: 0, Line: 1, Col: 1}, {Offset: 9, Line: 2, Col: 1}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected %v, got %v", want, got)
+	}
+}
+
+func TestValidateLines(t *testing.T) {
+	chunk := linesChunk
+	linesChunk = 100
+	defer func() { linesChunk = chunk }()
+
+	doc, lines, valid := sampleLines(300)
+
+	var want []int
+	for i, ok := range valid {
+		if !ok {
+			want = append(want, lines[i])
+		}
+	}
+
+	var got []int
+	for rec := range ValidateLines(strings.NewReader(doc), 3) {
+		if rec.Err == nil || rec.Value != nil {
+			t.Errorf("unexpected record %+v", rec)
+		}
+		got = append(got, rec.Line)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("expected invalid lines %v, got %v", want, got)
+	}
+}
+
+func TestDecodeLinesLongLine(t *testing.T) {
+	chunk := linesChunk
+	linesChunk = 16
+	defer func() { linesChunk = chunk }()
+
+	long := `"` + strings.Repeat("x", 100) + `"`
+	doc := "1\n" + long + "\n{\"a\": " + long + "}"
+
+	var got []string
+	for rec := range DecodeLines(strings.NewReader(doc), 2) {
+		if rec.Err != nil {
+			t.Fatalf("line %d: %v", rec.Line, rec.Err)
+		}
+		got = append(got, rec.Value.String())
+	}
+	if len(got) != 3 || got[2] != `{"a":`+long+`}` {
+		t.Errorf("unexpected records %v", got)
+	}
+}
+
+func TestDecodeLinesReadError(t *testing.T) {
+	chunk := linesChunk
+	linesChunk = 8
+	defer func() { linesChunk = chunk }()
+
+	broken := errors.New("broken")
+	r := io.MultiReader(strings.NewReader("1\n2\n3\n{\"a\":"), iotest.ErrReader(broken))
+
+	var got []Record
+	for rec := range DecodeLines(r, 2) {
+		got = append(got, rec)
+	}
+	if len(got) != 4 || got[2].Value.String() != "3" {
+		t.Fatalf("unexpected records %+v", got)
+	}
+	if !errors.Is(got[3].Err, broken) || got[3].Line != 4 {
+		t.Errorf("expected the read error on line 4, got %+v", got[3])
+	}
+}
+
+func TestDecodeLinesStop(t *testing.T) {
+	chunk := linesChunk
+	linesChunk = 32
+	defer func() { linesChunk = chunk }()
+
+	doc, _, _ := sampleLines(1000)
+
+	n := 0
+	for range DecodeLines(strings.NewReader(doc), 4) {
+		n++
+		if n == 10 {
+			break
+		}
+	}
+	if n != 10 {
+		t.Errorf("expected to stop after 10 records, got %d", n)
+	}
+}
@@ -1,39 +1,63 @@
 package parser
 
 import (
-	"bufio"
-	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"strconv"
 )
 
 type Parser struct {
+	lexer  *Lexer
 	tokens []Token
+	tape   *Tape
 	curIdx int
 	stack  []TokenType
+	// ctx is checked every checkEvery values while parsing
+	ctx    context.Context
+	values int
+	tracer Tracer
 }
 
 func (r *Parser) Parse() (bool, error) {
+	return r.ParseContext(context.Background())
+}
 
-	for r.curIdx < len(r.tokens)-1 && r.tokens[r.curIdx].TokenType != EOF {
-		err := r.parseValue()
-		if err != nil {
-			return false, err
-		}
-		if len(r.tokens) < r.curIdx {
-			return false, fmt.Errorf("sequence is never finished")
-		}
-		if len(r.stack) == 0 && r.curIdx+1 < len(r.tokens)-1 && r.tokens[r.curIdx+1].TokenType != EOF {
-			return false, fmt.Errorf("incorrect json structure")
+// ParseContext is Parse, giving up with a ContextError once ctx is done.
+func (r *Parser) ParseContext(ctx context.Context) (bool, error) {
+	r.ctx, r.values = ctx, 0
+
+	err := r.parse()
+	if err != nil {
+		if r.tracer != nil {
+			r.tracer.OnError(err, r.token(r.curIdx))
 		}
-		r.curIdx++
+		return false, err
+	}
+	return true, nil
+}
+
+func (r *Parser) parse() error {
+	if len(r.tokens) == 0 || r.tokens[0].TokenType == EOF {
+		return fmt.Errorf("empty input")
+	}
+
+	if err := r.parseValue(); err != nil {
+		return err
+	}
 
+	// parseValue stops on the last token of the value
+	r.curIdx++
+	if r.token(r.curIdx).TokenType != EOF {
+		return fmt.Errorf("incorrect json structure")
 	}
+	r.consume(r.curIdx)
+
 	if len(r.stack) != 0 {
-		return false, fmt.Errorf("braces or brackets are inbalanced")
+		return fmt.Errorf("braces or brackets are inbalanced")
 	}
 
-	return true, nil
+	return nil
 }
 
 func (r *Parser) GetTokens() []string {
@@ -54,6 +78,14 @@ func (r *Parser) GetStack() []TokenType {
 func (r *Parser) parseValue() error {
 	cur := r.tokens[r.curIdx]
 
+	r.values++
+	if r.values%checkEvery == 0 && r.ctx != nil {
+		if err := checkContext(r.ctx, cur.Pos.Offset); err != nil {
+			return err
+		}
+	}
+	r.consume(r.curIdx)
+
 	switch cur.TokenType {
 	case LEFT_BRACE:
 		return r.parseObj()
@@ -76,40 +108,76 @@ func (r *Parser) parseValue() error {
 
 // gotta figure out how to escape random json structure on outer levels
 func NewParser(input []byte) (*Parser, error) {
+	return NewParserContext(context.Background(), input)
+}
+
+// NewParserContext is NewParser, giving up with a ContextError once ctx is
+// done while the input is tokenized.
+func NewParserContext(ctx context.Context, input []byte) (*Parser, error) {
 
-	lexer := NewLexer(bufio.NewReader(bytes.NewReader(input)))
+	lexer := NewLexerBytes(input)
 
 	if lexer.Tokens == nil {
 		return nil, fmt.Errorf("empty tokens")
 	}
 
-	tokens, err := lexer.Tokenize()
+	tokens, err := lexer.TokenizeContext(ctx)
 
 	if err != nil {
-		return nil, fmt.Errorf("unable to tokenize %v", err)
+		return nil, fmt.Errorf("unable to tokenize %w", err)
 	}
 
 	return &Parser{
+		lexer:  lexer,
 		tokens: tokens,
+		tape:   BuildTape(tokens),
 		curIdx: 0,
 		stack:  make([]TokenType, 0),
 	}, nil
 }
 
+// Reset tokenizes input for a new Parse, reusing the token slice, tape and
+// stack of the previous document, so a Parser can be kept in a sync.Pool.
+// The tokens and tape of the previous document are overwritten, values and
+// strings decoded from it stay as they are.
+func (r *Parser) Reset(input []byte) error {
+	if r.lexer == nil {
+		r.lexer = &Lexer{}
+	}
+	r.lexer.Reset(input)
+
+	tokens, err := r.lexer.Tokenize()
+	if err != nil {
+		r.tokens = nil
+		return fmt.Errorf("unable to tokenize %w", err)
+	}
+
+	if r.tape == nil {
+		r.tape = &Tape{}
+	}
+	r.tape.build(tokens)
+
+	r.tokens = tokens
+	r.curIdx = 0
+	r.stack = r.stack[:0]
+	r.ctx, r.values = nil, 0
+	return nil
+}
+
 func (r *Parser) parseRightBracket() error {
-	fmt.Println(r.stack, r.curIdx)
 	if r.last() != LEFT_BRACKET {
 		return fmt.Errorf("incorrect json structure (right bracket)")
 	}
+	r.consume(r.curIdx)
 	r.popStack()
 	return nil
 }
 
 func (r *Parser) parseRightBrace() error {
-	fmt.Println("parse right brace")
 	if r.last() != LEFT_BRACE {
 		return fmt.Errorf("incorrect json structure (right brace)")
 	}
+	r.consume(r.curIdx)
 	r.popStack()
 
 	return nil
@@ -150,56 +218,64 @@ func (r *Parser) parseNull() error {
 	return nil
 }
 
+// Tape returns the structural index of the parsed tokens.
+func (r *Parser) Tape() *Tape {
+	return r.tape
+}
+
+// closing returns the index of the token that closes the container opened
+// at r.curIdx
+func (r *Parser) closing(open, close TokenType) (int, error) {
+	if r.token(r.curIdx).TokenType != open {
+		return 0, fmt.Errorf("incorrect json structure, got %v", r.token(r.curIdx))
+	}
+
+	end := r.tape.Jump[r.curIdx]
+	if end < 0 || r.tokens[end].TokenType != close {
+		return 0, fmt.Errorf("braces or brackets are inbalanced")
+	}
+	return end, nil
+}
+
 func (r *Parser) parseArray() error {
+	end, err := r.closing(LEFT_BRACKET, RIGHT_BRACKET)
+	if err != nil {
+		return err
+	}
+
+	r.pushStack(LEFT_BRACKET)
+
 	// Move past the left bracket
 	r.curIdx++
 
-	r.stack = append(r.stack, LEFT_BRACKET)
-
 	// Check if the array is empty
-	if r.curIdx < len(r.tokens)-1 && r.tokens[r.curIdx].TokenType == RIGHT_BRACKET {
+	if r.curIdx == end {
 		return r.parseRightBracket()
 	}
 
-	for r.curIdx < len(r.tokens)-1 && r.tokens[r.curIdx].TokenType != RIGHT_BRACKET {
-
-		if r.curIdx < len(r.tokens)-1 && r.tokens[r.curIdx].TokenType == RIGHT_BRACKET {
-			break
-		}
-
+	for {
 		// Parse the value (this will handle nested arrays)
-		err := r.parseValue()
-		if err != nil {
+		if err := r.parseValue(); err != nil {
 			return err
 		}
 
 		r.curIdx++
-
-		if r.curIdx < len(r.tokens)-1 && r.tokens[r.curIdx].TokenType != COMMA && r.tokens[r.curIdx].TokenType != RIGHT_BRACKET {
-			return fmt.Errorf("expected comma or closing bracket in array, but got %s %v", r.tokens[r.curIdx].Value, r.stack)
+		if r.curIdx == end {
+			break
 		}
 
-		if r.curIdx < len(r.tokens)-1 && r.tokens[r.curIdx].TokenType == COMMA && r.curIdx+1 < len(r.tokens)-1 && r.tokens[r.curIdx+1].TokenType == RIGHT_BRACKET {
-			return fmt.Errorf("extra comma %s %v", r.tokens[r.curIdx].Value, r.stack)
+		if r.tokens[r.curIdx].TokenType != COMMA {
+			return fmt.Errorf("expected comma or closing bracket in array, but got %s %v", r.tokens[r.curIdx].Value, r.stack)
 		}
+		r.consume(r.curIdx)
 
-		if r.curIdx+1 < len(r.tokens)-1 && r.tokens[r.curIdx].TokenType != RIGHT_BRACKET {
-			r.curIdx++
+		r.curIdx++
+		if r.curIdx == end {
+			return fmt.Errorf("extra comma %s %v", r.tokens[r.curIdx-1].Value, r.stack)
 		}
 	}
 
-	if r.tokens[r.curIdx].TokenType != RIGHT_BRACKET {
-		return fmt.Errorf("error incorrect json structure (right bracket), got %v", r.tokens[r.curIdx])
-	}
-
-	if r.last() != LEFT_BRACKET {
-		return fmt.Errorf("error incorrect json structure unbalanced brackets")
-	}
-
-	if len(r.stack) > 0 {
-		r.popStack()
-	}
-	return nil
+	return r.parseRightBracket()
 }
 
 func (r *Parser) ParseObj() error {
@@ -207,46 +283,41 @@ func (r *Parser) ParseObj() error {
 }
 
 func (r *Parser) parseObj() error {
+	end, err := r.closing(LEFT_BRACE, RIGHT_BRACE)
+	if err != nil {
+		return err
+	}
 
-	r.stack = append(r.stack, LEFT_BRACE)
+	r.pushStack(LEFT_BRACE)
 
 	r.curIdx++ //skip opening bracket {
 
-	if r.tokens[r.curIdx].TokenType == RIGHT_BRACE {
+	if r.curIdx == end {
 		return r.parseRightBrace()
 	}
 
-	for r.tokens[r.curIdx].TokenType != RIGHT_BRACE {
-		err := r.parseKeyvalue()
-
-		if err != nil {
+	for {
+		if err := r.parseKeyvalue(); err != nil {
 			return err
 		}
 
 		r.curIdx++
+		if r.curIdx == end {
+			break
+		}
 
-		if r.curIdx >= 0 && r.curIdx < len(r.tokens)-1 && r.tokens[r.curIdx-1].TokenType != COMMA && r.tokens[r.curIdx].TokenType != RIGHT_BRACE {
+		if r.tokens[r.curIdx].TokenType != COMMA {
 			return fmt.Errorf("expected comma, but got %v", r.tokens[r.curIdx])
 		}
+		r.consume(r.curIdx)
 
-		if r.tokens[r.curIdx-1].TokenType == COMMA && r.tokens[r.curIdx].TokenType == RIGHT_BRACE {
+		r.curIdx++
+		if r.curIdx == end {
 			return fmt.Errorf("extra comma")
 		}
 	}
 
-	if r.tokens[r.curIdx].TokenType != RIGHT_BRACE {
-		return fmt.Errorf("error incorrect json structure (right brace)")
-	}
-
-	if r.last() != LEFT_BRACE {
-		return fmt.Errorf("error incorrect json structure unbalanced braces")
-	}
-
-	if len(r.stack) > 0 {
-		r.popStack()
-	}
-
-	return nil
+	return r.parseRightBrace()
 }
 
 func (r *Parser) parseKeyvalue() error {
@@ -254,8 +325,9 @@ func (r *Parser) parseKeyvalue() error {
 	cur := r.tokens[r.curIdx]
 
 	if cur.TokenType != STRING {
-		return fmt.Errorf("incorrect json structure (object) 1, got: %s, prev: %v", cur.Value, r.tokens[r.curIdx-1])
+		return fmt.Errorf("incorrect json structure (object) 1, got: %s, prev: %v", cur.Value, r.token(r.curIdx-1))
 	}
+	r.consume(r.curIdx)
 
 	r.curIdx++
 
@@ -263,33 +335,61 @@ func (r *Parser) parseKeyvalue() error {
 	if r.tokens[r.curIdx].TokenType != COLON {
 		return fmt.Errorf("incorrect json structure (object) 2")
 	}
+	r.consume(r.curIdx)
 
 	//skip colon
 	r.curIdx++
 
-	err := r.parseValue()
-	if err != nil {
-		return err
-	}
+	// the value ends before the closing brace, which can't be a colon
+	return r.parseValue()
+}
 
-	if r.curIdx+1 < len(r.tokens)-1 && r.tokens[r.curIdx+1].TokenType == COMMA {
-		r.curIdx++
+func (r *Parser) pushStack(open TokenType) {
+	r.stack = append(r.stack, open)
+	if r.tracer != nil {
+		r.tracer.OnPush(r.token(r.curIdx), len(r.stack))
 	}
-
-	return nil
 }
 
 func (r *Parser) popStack() {
 	if len(r.stack) > 0 {
 		r.stack = r.stack[:len(r.stack)-1]
+		if r.tracer != nil {
+			r.tracer.OnPop(r.token(r.curIdx), len(r.stack))
+		}
+	}
+}
+
+// consume reports the token at i to the tracer
+func (r *Parser) consume(i int) {
+	if r.tracer != nil {
+		r.tracer.OnToken(r.token(i), i)
 	}
 }
 
+// last returns the innermost open container, EOF if there is none
 func (r *Parser) last() TokenType {
+	if len(r.stack) == 0 {
+		return EOF
+	}
 	return r.stack[len(r.stack)-1]
 }
 
+// token returns the token at i, or the final EOF token when i runs past the
+// end of the stream
+func (r *Parser) token(i int) Token {
+	if i < 0 || i >= len(r.tokens) {
+		if len(r.tokens) > 0 {
+			return r.tokens[len(r.tokens)-1]
+		}
+		return Token{TokenType: EOF}
+	}
+	return r.tokens[i]
+}
+
+// isValidNumber reports whether s is a number literal. Literals too large
+// or too small for a float64 are still valid JSON.
 func (r *Parser) isValidNumber(s string) bool {
 	_, err := strconv.ParseFloat(s, 64)
-	return err == nil
+	return err == nil || errors.Is(err, strconv.ErrRange)
 }
@@ -517,3 +517,88 @@ func TestDeeplyNestedObj(t *testing.T) {
 		}
 	}
 }
+
+func TestParseObjOutOfTokens(t *testing.T) {
+	for _, sample := range []string{"", "{", "{\"a\"", "{\"a\":", "{\"a\":1,", "[{"} {
+		p, err := NewParser([]byte(sample))
+		if err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+
+		if err := p.ParseObj(); err == nil {
+			t.Errorf("error should have been raised for %q", sample)
+		}
+	}
+}
+
+func TestLastOnEmptyStack(t *testing.T) {
+	p, _ := NewParser([]byte("]"))
+
+	if p.last() != EOF {
+		t.Errorf("expected EOF for an empty stack, got %v", p.last())
+	}
+
+	if err := p.parseRightBracket(); err == nil {
+		t.Errorf("error should have been raised")
+	}
+}
+
+func TestParserReset(t *testing.T) {
+	p, err := NewParser([]byte(`{"a": [1, 2]}`))
+	if err != nil {
+		t.Fatalf("error creating parser %v", err)
+	}
+	if _, err := p.Parse(); err != nil {
+		t.Fatalf("error parsing %v", err)
+	}
+
+	for _, c := range []struct {
+		input string
+		ok    bool
+	}{
+		{`{"b": {"c": null}}`, true},
+		{`{"b": 1,}`, false},
+		{`"x"`, true},
+		{`{"b": @}`, false},
+		{``, false},
+		{`{"d": {}}`, true},
+	} {
+		err := p.Reset([]byte(c.input))
+		if err == nil {
+			_, err = p.Parse()
+		}
+		if (err == nil) != c.ok {
+			t.Errorf("%q: unexpected result %v", c.input, err)
+		}
+	}
+
+	v, err := p.Value()
+	if err != nil || v.Lookup("/d").Kind != OBJECT_VALUE {
+		t.Errorf("unexpected value %v %v", v, err)
+	}
+	if p.Tape().Jump[0] != len(p.tokens)-2 {
+		t.Errorf("tape was not rebuilt, got %v", p.Tape().Jump)
+	}
+}
+
+func TestParserResetKeepsStrings(t *testing.T) {
+	p, err := NewParser([]byte(`{"name": "alice"}`))
+	if err != nil {
+		t.Fatalf("error creating parser %v", err)
+	}
+	v, err := p.Value()
+	if err != nil {
+		t.Fatalf("error decoding %v", err)
+	}
+	name, key := v.Get("name").Str, v.Members[0].Key
+
+	if err := p.Reset([]byte(`{"xxxx": "bobby"}`)); err != nil {
+		t.Fatalf("error resetting %v", err)
+	}
+	if _, err := p.Value(); err != nil {
+		t.Fatalf("error decoding %v", err)
+	}
+	if name != "alice" || key != "name" {
+		t.Errorf("strings of the previous document changed to %q %q", key, name)
+	}
+}
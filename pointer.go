@@ -0,0 +1,63 @@
+package parser
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Paths reported by this package are JSON Pointers (RFC 6901), e.g. "/a/0/b".
+// The empty pointer "" refers to the whole document.
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+func pointerAppendKey(path, key string) string {
+	return path + "/" + pointerEscaper.Replace(key)
+}
+
+func pointerAppendIndex(path string, i int) string {
+	return path + "/" + strconv.Itoa(i)
+}
+
+var pointerUnescaper = strings.NewReplacer("~1", "/", "~0", "~")
+
+// Lookup resolves a JSON Pointer against v. It returns nil if the pointer is
+// malformed or does not refer to an existing value.
+func (v *Value) Lookup(ptr string) *Value {
+	if ptr == "" {
+		return v
+	}
+	if !strings.HasPrefix(ptr, "/") {
+		return nil
+	}
+
+	cur := v
+	for _, tok := range strings.Split(ptr[1:], "/") {
+		tok = pointerUnescaper.Replace(tok)
+		switch cur.Kind {
+		case OBJECT_VALUE:
+			cur = cur.Get(tok)
+		case ARRAY_VALUE:
+			i, ok := pointerIndex(tok, len(cur.Elems))
+			if !ok {
+				return nil
+			}
+			cur = cur.Elems[i]
+		default:
+			return nil
+		}
+		if cur == nil {
+			return nil
+		}
+	}
+	return cur
+}
+
+// pointerIndex parses a reference token as an index into an array of n
+// elements
+func pointerIndex(tok string, n int) (int, bool) {
+	i, err := strconv.Atoi(tok)
+	if err != nil || i < 0 || i >= n || tok[0] == '+' || (len(tok) > 1 && tok[0] == '0') {
+		return 0, false
+	}
+	return i, true
+}
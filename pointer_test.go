@@ -0,0 +1,31 @@
+package parser
+
+import "testing"
+
+func TestLookup(t *testing.T) {
+	v, err := ParseValue([]byte("{\"a\": [10, {\"b/c\": 1, \"d~e\": 2}], \"\": 3}"))
+	if err != nil {
+		t.Fatalf("error parsing %v", err)
+	}
+
+	cases := map[string]string{
+		"":          v.String(),
+		"/a/0":      "10",
+		"/a/1/b~1c": "1",
+		"/a/1/d~0e": "2",
+		"/":         "3",
+	}
+
+	for ptr, want := range cases {
+		got := v.Lookup(ptr)
+		if got == nil || got.String() != want {
+			t.Errorf("%q: expected %s, got %v", ptr, want, got)
+		}
+	}
+
+	for _, ptr := range []string{"a", "/a/2", "/a/01", "/a/+1", "/a/0/x", "/missing"} {
+		if got := v.Lookup(ptr); got != nil {
+			t.Errorf("%q: expected nil, got %v", ptr, got)
+		}
+	}
+}
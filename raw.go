@@ -0,0 +1,258 @@
+package parser
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// RawValue is a validated JSON value that is only decoded when it is used.
+// It refers to the byte span of the value, which ParseRaw recorded while
+// checking the document; the children of an array or object are picked
+// out of those spans the first time one of them is accessed, and scalars
+// are decoded by Value. A RawValue caches what it has scanned, so it must
+// not be used from several goroutines at once.
+type RawValue struct {
+	src   string
+	spans []rawSpan
+	// idx is the index of the span of the value
+	idx   int
+	kind  Kind
+	known bool
+	elems []*RawValue
+	keys  []string
+}
+
+// rawSpan is where a value or an object key sits in the document. Spans
+// are in document order, so the children of a container follow it.
+type rawSpan struct {
+	start, end int
+	// next is the index of the span after the value and its children
+	next int
+}
+
+// ParseRaw validates input and returns a handle on its top level value.
+// Checking the document and recording the span of every value and key is
+// a single pass; accessing children afterwards only looks at the spans,
+// and decoding a value reads just its own bytes.
+func ParseRaw(input []byte) (*RawValue, error) {
+	src := string(input)
+	spans, err := scanRaw(src)
+	if err != nil {
+		return nil, err
+	}
+	return newRawValue(src, spans, 0), nil
+}
+
+// scanRaw lexes and checks src like validate, recording the spans of its
+// values and keys on the way
+func scanRaw(src string) ([]rawSpan, error) {
+	checker := checkerPool.Get().(*syntaxChecker)
+	defer checkerPool.Put(checker)
+	checker.reset()
+
+	lexer := Lexer{src: src, pos: Position{Line: 1, Col: 1}}
+	spans := make([]rawSpan, 0, len(src)/8+1)
+	// open holds the spans of the unclosed containers
+	var open []int
+
+	for {
+		t, err := lexer.nextBytes()
+		if err == nil {
+			err = checker.next(t)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch t.TokenType {
+		case LEFT_BRACE, LEFT_BRACKET:
+			open = append(open, len(spans))
+			spans = append(spans, rawSpan{start: t.Pos.Offset})
+		case RIGHT_BRACE, RIGHT_BRACKET:
+			i := open[len(open)-1]
+			open = open[:len(open)-1]
+			spans[i].end, spans[i].next = lexer.pos.Offset, len(spans)
+		case STRING, NUMBER, TRUE, FALSE, NULL:
+			spans = append(spans, rawSpan{start: t.Pos.Offset, end: lexer.pos.Offset, next: len(spans) + 1})
+		case EOF:
+			return spans, nil
+		}
+	}
+}
+
+// newRawValue returns the value of the span at idx
+func newRawValue(src string, spans []rawSpan, idx int) *RawValue {
+	v := &RawValue{src: src, spans: spans, idx: idx}
+
+	switch src[spans[idx].start] {
+	case 'n':
+		v.kind = NULL_VALUE
+	case 't', 'f':
+		v.kind = BOOL_VALUE
+	case '"':
+		v.kind = STRING_VALUE
+	case '[':
+		v.kind = ARRAY_VALUE
+	case '{':
+		v.kind = OBJECT_VALUE
+	default:
+		v.kind = NUMBER_VALUE
+	}
+	return v
+}
+
+// scan locates the children of an array or object
+func (v *RawValue) scan() {
+	if v.known {
+		return
+	}
+	v.known = true
+
+	if v.kind != ARRAY_VALUE && v.kind != OBJECT_VALUE {
+		return
+	}
+
+	for i := v.idx + 1; i < v.spans[v.idx].next; i = v.spans[i].next {
+		if v.kind == OBJECT_VALUE {
+			// the document was validated, so the key is well formed
+			k := v.spans[i]
+			key, _ := unquote(v.src[k.start+1 : k.end-1])
+			v.keys = append(v.keys, key)
+			i++
+		}
+		v.elems = append(v.elems, newRawValue(v.src, v.spans, i))
+	}
+}
+
+// Kind returns the type of the value.
+func (v *RawValue) Kind() Kind {
+	return v.kind
+}
+
+// Pos returns where the value starts in the document.
+// The line and column are counted when asked for.
+func (v *RawValue) Pos() Position {
+	off := v.spans[v.idx].start
+	before := v.src[:off]
+	return Position{
+		Offset: off,
+		Line:   strings.Count(before, "\n") + 1,
+		Col:    off - strings.LastIndexByte(before, '\n'),
+	}
+}
+
+// Raw returns the source text of the value.
+func (v *RawValue) Raw() string {
+	sp := v.spans[v.idx]
+	return v.src[sp.start:sp.end]
+}
+
+// Len returns the number of elements or members of an array or object and
+// 0 for anything else.
+func (v *RawValue) Len() int {
+	v.scan()
+	return len(v.elems)
+}
+
+// Index returns the i-th element of an array, or nil if v is not an array
+// or i is out of range.
+func (v *RawValue) Index(i int) *RawValue {
+	if v == nil || v.kind != ARRAY_VALUE {
+		return nil
+	}
+	v.scan()
+	if i < 0 || i >= len(v.elems) {
+		return nil
+	}
+	return v.elems[i]
+}
+
+// Get returns the value of the last member named key, or nil if v is not
+// an object or has no such member.
+func (v *RawValue) Get(key string) *RawValue {
+	if v == nil || v.kind != OBJECT_VALUE {
+		return nil
+	}
+	v.scan()
+	for i := len(v.keys) - 1; i >= 0; i-- {
+		if v.keys[i] == key {
+			return v.elems[i]
+		}
+	}
+	return nil
+}
+
+// Keys returns the keys of an object in document order.
+func (v *RawValue) Keys() []string {
+	if v == nil || v.kind != OBJECT_VALUE {
+		return nil
+	}
+	v.scan()
+	return slices.Clone(v.keys)
+}
+
+// Lookup resolves a JSON Pointer against v, scanning only the containers
+// on the way. It returns nil if the pointer is malformed or does not refer
+// to an existing value.
+func (v *RawValue) Lookup(ptr string) *RawValue {
+	if ptr == "" {
+		return v
+	}
+	if !strings.HasPrefix(ptr, "/") {
+		return nil
+	}
+
+	cur := v
+	for _, tok := range strings.Split(ptr[1:], "/") {
+		tok = pointerUnescaper.Replace(tok)
+		switch cur.kind {
+		case OBJECT_VALUE:
+			cur = cur.Get(tok)
+		case ARRAY_VALUE:
+			i, ok := pointerIndex(tok, cur.Len())
+			if !ok {
+				return nil
+			}
+			cur = cur.Index(i)
+		default:
+			return nil
+		}
+		if cur == nil {
+			return nil
+		}
+	}
+	return cur
+}
+
+// Value decodes v and everything below it.
+func (v *RawValue) Value() (*Value, error) {
+	end := v.spans[v.idx].end
+	l := &Lexer{src: v.src[:end], pos: v.Pos()}
+
+	var tokens []Token
+	for {
+		t, err := l.nextBytes()
+		if err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, t)
+		if t.TokenType == EOF {
+			break
+		}
+	}
+
+	idx := 0
+	return buildValue(tokens, &idx)
+}
+
+// Str returns the decoded value of a string, or an error for any other
+// kind.
+func (v *RawValue) Str() (string, error) {
+	if v.kind != STRING_VALUE {
+		return "", fmt.Errorf("%s at %v is not a string", v.kind, v.Pos())
+	}
+	// strip the quotes
+	sp := v.spans[v.idx]
+	return unquote(v.src[sp.start+1 : sp.end-1])
+}
@@ -0,0 +1,85 @@
+package parser
+
+import "testing"
+
+const rawSample = "{\"id\": 7, \"name\": \"a\\u00e9\",\n \"tags\": [\"x\", {\"deep\": [1, 2]}], \"id\": 8}"
+
+func TestParseRaw(t *testing.T) {
+	v, err := ParseRaw([]byte(rawSample))
+	if err != nil {
+		t.Fatalf("error parsing %v", err)
+	}
+
+	if v.Kind() != OBJECT_VALUE || v.Len() != 4 {
+		t.Fatalf("expected object with 4 members, got %v %d", v.Kind(), v.Len())
+	}
+
+	if id := v.Get("id"); id.Raw() != "8" {
+		t.Errorf("expected the last id, got %s", id.Raw())
+	}
+
+	if s, err := v.Get("name").Str(); err != nil || s != "aé" {
+		t.Errorf("unexpected name %q %v", s, err)
+	}
+
+	tags := v.Get("tags")
+	if tags.Raw() != `["x", {"deep": [1, 2]}]` || tags.Pos().Line != 2 || tags.Pos().Col != 10 {
+		t.Errorf("unexpected span %s at %v", tags.Raw(), tags.Pos())
+	}
+
+	deep := v.Lookup("/tags/1/deep/1")
+	if deep == nil || deep.Raw() != "2" || deep.Kind() != NUMBER_VALUE {
+		t.Fatalf("unexpected lookup result %v", deep)
+	}
+
+	if v.Lookup("/tags/2") != nil || v.Lookup("/name/0") != nil || v.Get("missing") != nil {
+		t.Errorf("expected nil for missing values")
+	}
+
+	if keys := v.Keys(); len(keys) != 4 || keys[2] != "tags" {
+		t.Errorf("unexpected keys %v", keys)
+	}
+}
+
+func TestParseRawLazy(t *testing.T) {
+	v, err := ParseRaw([]byte(rawSample))
+	if err != nil {
+		t.Fatalf("error parsing %v", err)
+	}
+
+	v.Get("id")
+	tags := v.elems[2]
+	if tags.known || tags.elems != nil {
+		t.Errorf("children of an untouched array should not be scanned")
+	}
+}
+
+func TestRawValueDecode(t *testing.T) {
+	v, err := ParseRaw([]byte(rawSample))
+	if err != nil {
+		t.Fatalf("error parsing %v", err)
+	}
+
+	tags, err := v.Get("tags").Value()
+	if err != nil {
+		t.Fatalf("error decoding %v", err)
+	}
+	if tags.String() != `["x",{"deep":[1,2]}]` {
+		t.Errorf("unexpected value %s", tags)
+	}
+	if p := tags.Elems[1].Pos; p.Line != 2 || p.Col != 16 {
+		t.Errorf("unexpected position %v", p)
+	}
+
+	if _, err := v.Get("id").Str(); err == nil {
+		t.Errorf("error should have been raised")
+	}
+}
+
+func TestParseRawInvalid(t *testing.T) {
+	for _, s := range []string{`{"a": [1, 2,]}`, `{"a" 1}`, `[1] [2]`, ``} {
+		if _, err := ParseRaw([]byte(s)); err == nil {
+			t.Errorf("%s: error should have been raised", s)
+		}
+	}
+}
@@ -0,0 +1,521 @@
+package parser
+
+import (
+	"fmt"
+	"math/big"
+	"net/netip"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Schema is a compiled JSON Schema (draft 2020-12). Only references within
+// the same document ("#" and "#/json/pointer") are supported.
+type Schema struct {
+	root    *Value
+	regexes map[string]*regexp.Regexp
+	// compiled holds the subschemas compile has seen, so that references
+	// are followed once and cycles end
+	compiled map[*Value]bool
+}
+
+// SchemaError is a single violation found while validating an instance.
+// InstancePath and SchemaPath are JSON Pointers into the instance and the
+// schema, Pos is the position of the offending value in the instance.
+type SchemaError struct {
+	InstancePath string
+	SchemaPath   string
+	Pos          Position
+	Message      string
+}
+
+func (e SchemaError) Error() string {
+	return fmt.Sprintf("%v: %s (instance %q, schema %q)", e.Pos, e.Message, e.InstancePath, e.SchemaPath)
+}
+
+// maxSchemaDepth bounds $ref recursion for schemas that refer to themselves
+// without descending into the instance
+const maxSchemaDepth = 512
+
+func CompileSchema(input []byte) (*Schema, error) {
+	root, err := ParseValue(input)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema document: %v", err)
+	}
+
+	s := &Schema{root: root, regexes: map[string]*regexp.Regexp{}, compiled: map[*Value]bool{}}
+	if err := s.compile(root, ""); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// compile checks the structure of every subschema, compiles its patterns
+// and makes sure references resolve
+func (s *Schema) compile(sch *Value, path string) error {
+	if s.compiled[sch] {
+		return nil
+	}
+	s.compiled[sch] = true
+
+	if sch.Kind == BOOL_VALUE {
+		return nil
+	}
+	if sch.Kind != OBJECT_VALUE {
+		return fmt.Errorf("schema at %q must be an object or boolean, got %v", path, sch.Kind)
+	}
+
+	if p := sch.Get("pattern"); p != nil {
+		if err := s.compileRegex(p, pointerAppendKey(path, "pattern")); err != nil {
+			return err
+		}
+	}
+
+	if ref := sch.Get("$ref"); ref != nil {
+		if ref.Kind != STRING_VALUE {
+			return fmt.Errorf("$ref at %q must be a string", path)
+		}
+		target := s.resolve(ref.Str)
+		if target == nil {
+			return fmt.Errorf("unresolvable $ref %q at %q", ref.Str, path)
+		}
+		// the target may sit under a keyword compile does not descend
+		// into, e.g. definitions
+		if err := s.compile(target, ref.Str[1:]); err != nil {
+			return err
+		}
+	}
+
+	if t := sch.Get("type"); t != nil {
+		names := []*Value{t}
+		if t.Kind == ARRAY_VALUE {
+			names = t.Elems
+		}
+		for _, n := range names {
+			if n.Kind != STRING_VALUE || !isSchemaType(n.Str) {
+				return fmt.Errorf("invalid type %v at %q", n, path)
+			}
+		}
+	}
+
+	for _, kw := range []string{"properties", "patternProperties", "$defs"} {
+		m := sch.Get(kw)
+		if m == nil {
+			continue
+		}
+		if m.Kind != OBJECT_VALUE {
+			return fmt.Errorf("%s at %q must be an object", kw, path)
+		}
+		for _, member := range m.Members {
+			if kw == "patternProperties" {
+				key := &Value{Kind: STRING_VALUE, Str: member.Key}
+				if err := s.compileRegex(key, pointerAppendKey(path, kw)); err != nil {
+					return err
+				}
+			}
+			if err := s.compile(member.Value, pointerAppendKey(pointerAppendKey(path, kw), member.Key)); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, kw := range []string{"allOf", "anyOf", "oneOf", "prefixItems"} {
+		l := sch.Get(kw)
+		if l == nil {
+			continue
+		}
+		if l.Kind != ARRAY_VALUE || len(l.Elems) == 0 {
+			return fmt.Errorf("%s at %q must be a non-empty array", kw, path)
+		}
+		for i, e := range l.Elems {
+			if err := s.compile(e, pointerAppendIndex(pointerAppendKey(path, kw), i)); err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, kw := range []string{"not", "items", "additionalProperties"} {
+		if sub := sch.Get(kw); sub != nil {
+			if err := s.compile(sub, pointerAppendKey(path, kw)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *Schema) compileRegex(p *Value, path string) error {
+	if p.Kind != STRING_VALUE {
+		return fmt.Errorf("pattern at %q must be a string", path)
+	}
+	re, err := regexp.Compile(p.Str)
+	if err != nil {
+		return fmt.Errorf("invalid pattern at %q: %v", path, err)
+	}
+	s.regexes[p.Str] = re
+	return nil
+}
+
+func (s *Schema) resolve(ref string) *Value {
+	if !strings.HasPrefix(ref, "#") {
+		return nil
+	}
+	ptr, err := url.PathUnescape(ref[1:])
+	if err != nil {
+		return nil
+	}
+	return s.root.Lookup(ptr)
+}
+
+// Validate parses input and checks it against the schema. The error is only
+// set if input is not valid JSON; schema violations are returned as a list.
+func (s *Schema) Validate(input []byte) ([]SchemaError, error) {
+	v, err := ParseValue(input)
+	if err != nil {
+		return nil, err
+	}
+	return s.ValidateValue(v), nil
+}
+
+func (s *Schema) ValidateValue(v *Value) []SchemaError {
+	c := &validation{s: s, errs: []SchemaError{}}
+	c.validate(s.root, "", v, "")
+	return c.errs
+}
+
+type validation struct {
+	s     *Schema
+	errs  []SchemaError
+	depth int
+}
+
+func (c *validation) fail(spath string, v *Value, ipath string, format string, args ...any) {
+	c.errs = append(c.errs, SchemaError{
+		InstancePath: ipath,
+		SchemaPath:   spath,
+		Pos:          v.Pos,
+		Message:      fmt.Sprintf(format, args...),
+	})
+}
+
+// valid reports whether v matches sch without recording any errors
+func (c *validation) valid(sch *Value, spath string, v *Value, ipath string) bool {
+	sub := &validation{s: c.s, depth: c.depth}
+	sub.validate(sch, spath, v, ipath)
+	return len(sub.errs) == 0
+}
+
+func (c *validation) validate(sch *Value, spath string, v *Value, ipath string) {
+	if sch.Kind == BOOL_VALUE {
+		if !sch.Bool {
+			c.fail(spath, v, ipath, "no value is allowed here")
+		}
+		return
+	}
+
+	c.depth++
+	defer func() { c.depth-- }()
+	if c.depth > maxSchemaDepth {
+		c.fail(spath, v, ipath, "schema nesting is too deep")
+		return
+	}
+
+	kw := func(name string) (*Value, string) {
+		return sch.Get(name), pointerAppendKey(spath, name)
+	}
+
+	if ref, p := kw("$ref"); ref != nil {
+		target := c.s.resolve(ref.Str)
+		if target == nil || target.Kind != OBJECT_VALUE && target.Kind != BOOL_VALUE {
+			c.fail(p, v, ipath, "$ref %s does not refer to a schema", quote(ref.Str))
+		} else {
+			c.validate(target, p, v, ipath)
+		}
+	}
+
+	if t, p := kw("type"); t != nil {
+		c.checkType(t, p, v, ipath)
+	}
+
+	if e, p := kw("enum"); e != nil && e.Kind == ARRAY_VALUE {
+		found := false
+		for _, allowed := range e.Elems {
+			if Equal(allowed, v) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			c.fail(p, v, ipath, "value %v is not one of %v", v, e)
+		}
+	}
+
+	if k, p := kw("const"); k != nil && !Equal(k, v) {
+		c.fail(p, v, ipath, "value %v does not equal %v", v, k)
+	}
+
+	switch v.Kind {
+	case NUMBER_VALUE:
+		c.checkNumber(sch, spath, v, ipath)
+	case STRING_VALUE:
+		c.checkString(sch, spath, v, ipath)
+	case ARRAY_VALUE:
+		c.checkArray(sch, spath, v, ipath)
+	case OBJECT_VALUE:
+		c.checkObject(sch, spath, v, ipath)
+	}
+
+	if allOf, p := kw("allOf"); allOf != nil {
+		for i, sub := range allOf.Elems {
+			c.validate(sub, pointerAppendIndex(p, i), v, ipath)
+		}
+	}
+
+	if anyOf, p := kw("anyOf"); anyOf != nil {
+		matched := false
+		for i, sub := range anyOf.Elems {
+			if c.valid(sub, pointerAppendIndex(p, i), v, ipath) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			c.fail(p, v, ipath, "value does not match any schema in anyOf")
+		}
+	}
+
+	if oneOf, p := kw("oneOf"); oneOf != nil {
+		matched := 0
+		for i, sub := range oneOf.Elems {
+			if c.valid(sub, pointerAppendIndex(p, i), v, ipath) {
+				matched++
+			}
+		}
+		if matched != 1 {
+			c.fail(p, v, ipath, "value matches %d schemas in oneOf, expected exactly 1", matched)
+		}
+	}
+
+	if not, p := kw("not"); not != nil && c.valid(not, p, v, ipath) {
+		c.fail(p, v, ipath, "value must not match the schema in not")
+	}
+}
+
+func isSchemaType(name string) bool {
+	switch name {
+	case "null", "boolean", "object", "array", "number", "string", "integer":
+		return true
+	}
+	return false
+}
+
+func (c *validation) checkType(t *Value, spath string, v *Value, ipath string) {
+	names := []*Value{t}
+	if t.Kind == ARRAY_VALUE {
+		names = t.Elems
+	}
+
+	for _, n := range names {
+		if n.Str == v.Kind.String() {
+			return
+		}
+		if n.Str == "integer" && v.Kind == NUMBER_VALUE {
+			if r, ok := numberRat(v); ok && r.IsInt() {
+				return
+			}
+		}
+	}
+
+	c.fail(spath, v, ipath, "expected %v, got %v", t, v.Kind)
+}
+
+func numberRat(v *Value) (*big.Rat, bool) {
+	return new(big.Rat).SetString(v.Number)
+}
+
+func (c *validation) checkNumber(sch *Value, spath string, v *Value, ipath string) {
+	n, ok := numberRat(v)
+	if !ok {
+		return
+	}
+
+	limits := []struct {
+		name  string
+		fails func(cmp int) bool
+		msg   string
+	}{
+		{"minimum", func(cmp int) bool { return cmp < 0 }, "less than"},
+		{"maximum", func(cmp int) bool { return cmp > 0 }, "greater than"},
+		{"exclusiveMinimum", func(cmp int) bool { return cmp <= 0 }, "less than or equal to"},
+		{"exclusiveMaximum", func(cmp int) bool { return cmp >= 0 }, "greater than or equal to"},
+	}
+
+	for _, l := range limits {
+		lim := sch.Get(l.name)
+		if lim == nil || lim.Kind != NUMBER_VALUE {
+			continue
+		}
+		r, ok := numberRat(lim)
+		if ok && l.fails(n.Cmp(r)) {
+			c.fail(pointerAppendKey(spath, l.name), v, ipath, "%s is %s %s", v.Number, l.msg, lim.Number)
+		}
+	}
+
+	if m := sch.Get("multipleOf"); m != nil && m.Kind == NUMBER_VALUE {
+		r, ok := numberRat(m)
+		if ok && r.Sign() > 0 && !new(big.Rat).Quo(n, r).IsInt() {
+			c.fail(pointerAppendKey(spath, "multipleOf"), v, ipath, "%s is not a multiple of %s", v.Number, m.Number)
+		}
+	}
+}
+
+func (c *validation) checkString(sch *Value, spath string, v *Value, ipath string) {
+	length := utf8.RuneCountInString(v.Str)
+
+	if m := sch.Get("minLength"); m != nil && float64(length) < m.Float() {
+		c.fail(pointerAppendKey(spath, "minLength"), v, ipath, "string is shorter than %s characters", m.Number)
+	}
+
+	if m := sch.Get("maxLength"); m != nil && float64(length) > m.Float() {
+		c.fail(pointerAppendKey(spath, "maxLength"), v, ipath, "string is longer than %s characters", m.Number)
+	}
+
+	if p := sch.Get("pattern"); p != nil {
+		if re := c.s.regexes[p.Str]; re == nil {
+			c.fail(pointerAppendKey(spath, "pattern"), v, ipath, "pattern %s was not compiled", quote(p.Str))
+		} else if !re.MatchString(v.Str) {
+			c.fail(pointerAppendKey(spath, "pattern"), v, ipath, "string does not match pattern %s", quote(p.Str))
+		}
+	}
+
+	if f := sch.Get("format"); f != nil && f.Kind == STRING_VALUE && !checkFormat(f.Str, v.Str) {
+		c.fail(pointerAppendKey(spath, "format"), v, ipath, "string is not a valid %s", f.Str)
+	}
+}
+
+func (c *validation) checkArray(sch *Value, spath string, v *Value, ipath string) {
+	if m := sch.Get("minItems"); m != nil && float64(len(v.Elems)) < m.Float() {
+		c.fail(pointerAppendKey(spath, "minItems"), v, ipath, "array has fewer than %s items", m.Number)
+	}
+
+	if m := sch.Get("maxItems"); m != nil && float64(len(v.Elems)) > m.Float() {
+		c.fail(pointerAppendKey(spath, "maxItems"), v, ipath, "array has more than %s items", m.Number)
+	}
+
+	if u := sch.Get("uniqueItems"); u != nil && u.Bool {
+	outer:
+		for i := range v.Elems {
+			for j := i + 1; j < len(v.Elems); j++ {
+				if Equal(v.Elems[i], v.Elems[j]) {
+					c.fail(pointerAppendKey(spath, "uniqueItems"), v, ipath, "items %d and %d are equal", i, j)
+					break outer
+				}
+			}
+		}
+	}
+
+	prefix := 0
+	if pi := sch.Get("prefixItems"); pi != nil {
+		p := pointerAppendKey(spath, "prefixItems")
+		for i, sub := range pi.Elems {
+			if i >= len(v.Elems) {
+				break
+			}
+			c.validate(sub, pointerAppendIndex(p, i), v.Elems[i], pointerAppendIndex(ipath, i))
+		}
+		prefix = len(pi.Elems)
+	}
+
+	if items := sch.Get("items"); items != nil {
+		p := pointerAppendKey(spath, "items")
+		for i := prefix; i < len(v.Elems); i++ {
+			c.validate(items, p, v.Elems[i], pointerAppendIndex(ipath, i))
+		}
+	}
+}
+
+func (c *validation) checkObject(sch *Value, spath string, v *Value, ipath string) {
+	members := uniqueMembers(v)
+
+	if m := sch.Get("minProperties"); m != nil && float64(len(members)) < m.Float() {
+		c.fail(pointerAppendKey(spath, "minProperties"), v, ipath, "object has fewer than %s properties", m.Number)
+	}
+
+	if m := sch.Get("maxProperties"); m != nil && float64(len(members)) > m.Float() {
+		c.fail(pointerAppendKey(spath, "maxProperties"), v, ipath, "object has more than %s properties", m.Number)
+	}
+
+	if req := sch.Get("required"); req != nil && req.Kind == ARRAY_VALUE {
+		for _, name := range req.Elems {
+			if v.Get(name.Str) == nil {
+				c.fail(pointerAppendKey(spath, "required"), v, ipath, "missing required property %s", quote(name.Str))
+			}
+		}
+	}
+
+	props := sch.Get("properties")
+	patterns := sch.Get("patternProperties")
+	additional := sch.Get("additionalProperties")
+
+	for _, m := range members {
+		mpath := pointerAppendKey(ipath, m.Key)
+		matched := false
+
+		if sub := props.Get(m.Key); sub != nil {
+			matched = true
+			c.validate(sub, pointerAppendKey(pointerAppendKey(spath, "properties"), m.Key), m.Value, mpath)
+		}
+
+		if patterns != nil {
+			for _, pp := range patterns.Members {
+				if c.s.regexes[pp.Key].MatchString(m.Key) {
+					matched = true
+					c.validate(pp.Value, pointerAppendKey(pointerAppendKey(spath, "patternProperties"), pp.Key), m.Value, mpath)
+				}
+			}
+		}
+
+		if !matched && additional != nil {
+			c.validate(additional, pointerAppendKey(spath, "additionalProperties"), m.Value, mpath)
+		}
+	}
+}
+
+var (
+	timeRegex  = regexp.MustCompile(`^(?i)\d{2}:\d{2}:\d{2}(\.\d+)?(z|[+-]\d{2}:\d{2})$`)
+	emailRegex = regexp.MustCompile(`^[^@\s]+@[^@\s]+\.[^@\s]+$`)
+	uuidRegex  = regexp.MustCompile(`^(?i)[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}$`)
+)
+
+// checkFormat asserts the formats in common use; unknown formats are
+// treated as annotations and always pass
+func checkFormat(format, s string) bool {
+	switch format {
+	case "date-time":
+		_, err := time.Parse(time.RFC3339Nano, strings.ToUpper(s))
+		return err == nil
+	case "date":
+		_, err := time.Parse(time.DateOnly, s)
+		return err == nil
+	case "time":
+		return timeRegex.MatchString(s)
+	case "email":
+		return emailRegex.MatchString(s)
+	case "ipv4":
+		a, err := netip.ParseAddr(s)
+		return err == nil && a.Is4()
+	case "ipv6":
+		a, err := netip.ParseAddr(s)
+		return err == nil && a.Is6() && a.Zone() == ""
+	case "uri":
+		u, err := url.Parse(s)
+		return err == nil && u.IsAbs()
+	case "uuid":
+		return uuidRegex.MatchString(s)
+	}
+	return true
+}
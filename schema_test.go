@@ -0,0 +1,207 @@
+package parser
+
+import "testing"
+
+const personSchema = `{
+  "type": "object",
+  "required": ["name", "age"],
+  "properties": {
+    "name": {"type": "string", "minLength": 1, "pattern": "^[A-Z]"},
+    "age": {"type": "integer", "minimum": 0, "exclusiveMaximum": 150},
+    "email": {"type": "string", "format": "email"},
+    "tags": {"type": "array", "items": {"$ref": "#/$defs/tag"}, "uniqueItems": true},
+    "role": {"enum": ["admin", "user"]},
+    "point": {"prefixItems": [{"type": "number"}, {"type": "number"}], "items": false}
+  },
+  "patternProperties": {"^x-": {"type": "string"}},
+  "additionalProperties": false,
+  "$defs": {"tag": {"type": "string", "maxLength": 5}}
+}`
+
+func TestSchemaValid(t *testing.T) {
+	s, err := CompileSchema([]byte(personSchema))
+	if err != nil {
+		t.Fatalf("error compiling schema %v", err)
+	}
+
+	errs, err := s.Validate([]byte(`{"name": "Ann", "age": 30.0, "email": "ann@example.com", "tags": ["a", "b"], "role": "admin", "point": [1, 2], "x-note": "hi"}`))
+	if err != nil {
+		t.Fatalf("error parsing %v", err)
+	}
+
+	if len(errs) != 0 {
+		t.Errorf("expected no errors, got %v", errs)
+	}
+}
+
+func TestSchemaViolations(t *testing.T) {
+	s, err := CompileSchema([]byte(personSchema))
+	if err != nil {
+		t.Fatalf("error compiling schema %v", err)
+	}
+
+	errs, err := s.Validate([]byte("{\n  \"name\": \"ann\",\n  \"tags\": [\"toolong\", \"a\", \"a\"],\n  \"role\": \"root\",\n  \"point\": [1, 2, 3],\n  \"x-note\": 1,\n  \"other\": true\n}"))
+	if err != nil {
+		t.Fatalf("error parsing %v", err)
+	}
+
+	want := []struct{ instance, schema string }{
+		{"", "/required"},
+		{"/name", "/properties/name/pattern"},
+		{"/tags", "/properties/tags/uniqueItems"},
+		{"/tags/0", "/properties/tags/items/$ref/maxLength"},
+		{"/role", "/properties/role/enum"},
+		{"/point/2", "/properties/point/items"},
+		{"/x-note", "/patternProperties/^x-/type"},
+		{"/other", "/additionalProperties"},
+	}
+
+	if len(errs) != len(want) {
+		t.Fatalf("expected %d errors, got %d: %v", len(want), len(errs), errs)
+	}
+
+	for i, e := range errs {
+		if e.InstancePath != want[i].instance || e.SchemaPath != want[i].schema {
+			t.Errorf("expected %s %s, got %v", want[i].instance, want[i].schema, e)
+		}
+	}
+
+	if errs[1].Pos.Line != 2 || errs[1].Pos.Col != 11 {
+		t.Errorf("unexpected position %v", errs[1].Pos)
+	}
+}
+
+func TestSchemaCombinators(t *testing.T) {
+	s, err := CompileSchema([]byte(`{
+  "allOf": [{"type": ["number", "string"]}],
+  "anyOf": [{"type": "string"}, {"minimum": 10}],
+  "oneOf": [{"multipleOf": 2}, {"multipleOf": 3}, {"type": "string"}],
+  "not": {"const": 12}
+}`))
+	if err != nil {
+		t.Fatalf("error compiling schema %v", err)
+	}
+
+	cases := map[string]int{
+		`"text"`: 1,
+		`14`:     0,
+		`15`:     0,
+		`4`:      1,
+		`12`:     2,
+		`null`:   2,
+	}
+
+	for doc, n := range cases {
+		errs, err := s.Validate([]byte(doc))
+		if err != nil {
+			t.Fatalf("error parsing %v", err)
+		}
+		if len(errs) != n {
+			t.Errorf("%s: expected %d errors, got %v", doc, n, errs)
+		}
+	}
+}
+
+func TestSchemaFormats(t *testing.T) {
+	cases := []struct {
+		format, value string
+		ok            bool
+	}{
+		{"date-time", "2024-01-02T03:04:05Z", true},
+		{"date-time", "2024-01-02 03:04:05", false},
+		{"date", "2024-02-30", false},
+		{"time", "10:00:00+01:00", true},
+		{"ipv4", "192.168.0.1", true},
+		{"ipv4", "::1", false},
+		{"ipv6", "::1", true},
+		{"uri", "https://example.com/x", true},
+		{"uri", "/relative", false},
+		{"uuid", "123e4567-e89b-12d3-a456-426614174000", true},
+		{"unknown", "anything", true},
+	}
+
+	for _, c := range cases {
+		if checkFormat(c.format, c.value) != c.ok {
+			t.Errorf("format %s of %q: expected %v", c.format, c.value, c.ok)
+		}
+	}
+}
+
+func TestSchemaCompileErrors(t *testing.T) {
+	schemas := []string{
+		`{"$ref": "#/$defs/missing"}`,
+		`{"pattern": "("}`,
+		`{"type": "text"}`,
+		`{"properties": {"a": 1}}`,
+		`{"anyOf": []}`,
+		`[]`,
+	}
+
+	for _, s := range schemas {
+		if _, err := CompileSchema([]byte(s)); err == nil {
+			t.Errorf("%s: error should have been raised", s)
+		}
+	}
+}
+
+func TestSchemaRecursiveRef(t *testing.T) {
+	s, err := CompileSchema([]byte(`{"type": "object", "properties": {"child": {"$ref": "#"}}, "required": ["id"]}`))
+	if err != nil {
+		t.Fatalf("error compiling schema %v", err)
+	}
+
+	errs, err := s.Validate([]byte(`{"id": 1, "child": {"id": 2, "child": {}}}`))
+	if err != nil {
+		t.Fatalf("error parsing %v", err)
+	}
+
+	if len(errs) != 1 || errs[0].InstancePath != "/child/child" || errs[0].SchemaPath != "/properties/child/$ref/properties/child/$ref/required" {
+		t.Errorf("unexpected errors %v", errs)
+	}
+}
+
+func TestSchemaRefTargets(t *testing.T) {
+	for _, s := range []string{
+		`{"$ref": "#/x", "x": {"$ref": "#/nope"}}`,
+		`{"$ref": "#/x", "x": 5}`,
+		`{"$ref": "#/definitions/a", "definitions": {"a": {"pattern": "("}}}`,
+	} {
+		if _, err := CompileSchema([]byte(s)); err == nil {
+			t.Errorf("%s: error should have been raised", s)
+		}
+	}
+
+	cases := []struct {
+		schema, input string
+		errs          int
+	}{
+		{`{"$ref": "#/x", "x": {"pattern": "^a"}}`, `"abc"`, 0},
+		{`{"$ref": "#/x", "x": {"pattern": "^a"}}`, `"bc"`, 1},
+		{`{"$ref": "#/definitions/pos", "definitions": {"pos": {"type": "integer", "minimum": 1}}}`, `0`, 1},
+		{`{"$ref": "#/definitions/list", "definitions": {"list": {"type": "array", "items": {"$ref": "#/definitions/list"}}}}`, `[[], [[1]]]`, 1},
+	}
+	for _, c := range cases {
+		s, err := CompileSchema([]byte(c.schema))
+		if err != nil {
+			t.Errorf("%s: error compiling schema %v", c.schema, err)
+			continue
+		}
+		errs, err := s.Validate([]byte(c.input))
+		if err != nil {
+			t.Fatalf("error parsing %v", err)
+		}
+		if len(errs) != c.errs {
+			t.Errorf("%s with %s: expected %d errors, got %v", c.schema, c.input, c.errs, errs)
+		}
+	}
+
+	// a schema that was not compiled fails instead of panicking
+	root, err := ParseValue([]byte(`{"$ref": "#/x", "x": 5}`))
+	if err != nil {
+		t.Fatalf("error parsing %v", err)
+	}
+	s := &Schema{root: root}
+	if errs := s.ValidateValue(&Value{Kind: NULL_VALUE}); len(errs) != 1 {
+		t.Errorf("unexpected errors %v", errs)
+	}
+}
@@ -0,0 +1,74 @@
+package parser
+
+import "math/bits"
+
+// SWAR (SIMD within a register) helpers that look at 8 bytes per step.
+// Each returns how many leading bytes can be skipped; the byte loops in the
+// lexer take over from there.
+
+const (
+	swarOnes  = 0x0101010101010101
+	swarHighs = 0x8080808080808080
+)
+
+// useSWAR switches the fast paths on. Benchmarks turn it off to compare
+// against the byte loops.
+var useSWAR = true
+
+// swarZeros sets the high bit of every byte of x that is zero. Bits above
+// the first zero byte may be set spuriously, so only the lowest one can be
+// relied on.
+func swarZeros(x uint64) uint64 {
+	return (x - swarOnes) & ^x & swarHighs
+}
+
+// swarLess sets the high bit of every byte of x that is below n (n <= 128),
+// with the same caveat as swarZeros
+func swarLess(x uint64, n byte) uint64 {
+	return (x - swarOnes*uint64(n)) & ^x & swarHighs
+}
+
+// spanSpaces returns the number of leading ' ' bytes of s, which is how
+// indentation is usually written
+func spanSpaces[S ~string | ~[]byte](s S) int {
+	i := 0
+	if useSWAR {
+		for ; i+8 <= len(s); i += 8 {
+			if x := load64(s[i:]) ^ (swarOnes * ' '); x != 0 {
+				return i + bits.TrailingZeros64(x)/8
+			}
+		}
+	}
+	for i < len(s) && s[i] == ' ' {
+		i++
+	}
+	return i
+}
+
+// spanPlain returns the number of leading bytes of s that need no special
+// handling inside a string, i.e. everything but '"', '\\' and control
+// characters
+func spanPlain[S ~string | ~[]byte](s S) int {
+	i := 0
+	if useSWAR {
+		for ; i+8 <= len(s); i += 8 {
+			x := load64(s[i:])
+			mask := swarZeros(x^(swarOnes*'"')) | swarZeros(x^(swarOnes*'\\')) | swarLess(x, 0x20)
+			if mask != 0 {
+				return i + bits.TrailingZeros64(mask)/8
+			}
+		}
+	}
+	for i < len(s) && s[i] != '"' && s[i] != '\\' && s[i] >= 0x20 {
+		i++
+	}
+	return i
+}
+
+// load64 reads the first 8 bytes of s as a little endian word, which the
+// compiler turns into a single load
+func load64[S ~string | ~[]byte](s S) uint64 {
+	_ = s[7]
+	return uint64(s[0]) | uint64(s[1])<<8 | uint64(s[2])<<16 | uint64(s[3])<<24 |
+		uint64(s[4])<<32 | uint64(s[5])<<40 | uint64(s[6])<<48 | uint64(s[7])<<56
+}
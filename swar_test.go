@@ -0,0 +1,53 @@
+package parser
+
+import (
+	"math/rand/v2"
+	"strings"
+	"testing"
+)
+
+// withoutSWAR runs fn with the fast paths turned off
+func withoutSWAR(fn func()) {
+	useSWAR = false
+	defer func() { useSWAR = true }()
+	fn()
+}
+
+func TestSpanPlain(t *testing.T) {
+	// every byte value at every offset of a word and past it
+	for c := 0; c < 256; c++ {
+		for at := 0; at < 20; at++ {
+			s := strings.Repeat("a", at) + string([]byte{byte(c)}) + strings.Repeat("é", 4)
+
+			want := 0
+			withoutSWAR(func() { want = spanPlain(s) })
+
+			if got := spanPlain(s); got != want {
+				t.Fatalf("spanPlain(%q) = %d, expected %d", s, got, want)
+			}
+			if got := spanPlain([]byte(s)); got != want {
+				t.Fatalf("spanPlain([]byte(%q)) = %d, expected %d", s, got, want)
+			}
+		}
+	}
+}
+
+func TestSpanSpaces(t *testing.T) {
+	rng := rand.New(rand.NewPCG(1, 2))
+	for i := 0; i < 1000; i++ {
+		b := make([]byte, rng.IntN(40))
+		for j := range b {
+			b[j] = " \t\na"[rng.IntN(4)]
+			if rng.IntN(4) > 0 {
+				b[j] = ' '
+			}
+		}
+
+		want := 0
+		withoutSWAR(func() { want = spanSpaces(b) })
+
+		if got := spanSpaces(b); got != want {
+			t.Fatalf("spanSpaces(%q) = %d, expected %d", b, got, want)
+		}
+	}
+}
@@ -0,0 +1,112 @@
+package parser
+
+import "fmt"
+
+type syntaxState int
+
+const (
+	expectValue syntaxState = iota
+	expectFirstValue
+	expectFirstKey
+	expectKey
+	expectColon
+	expectCommaOrClose
+	expectEOF
+)
+
+// syntaxChecker validates a token stream one token at a time, keeping only
+// the stack of open containers. It is used where tokens are not collected,
+// e.g. when streaming.
+type syntaxChecker struct {
+	stack []TokenType
+	state syntaxState
+}
+
+func newSyntaxChecker() *syntaxChecker {
+	return &syntaxChecker{stack: make([]TokenType, 0, 16)}
+}
+
+func (c *syntaxChecker) reset() {
+	c.stack = c.stack[:0]
+	c.state = expectValue
+}
+
+// next checks that t may follow the tokens seen so far
+func (c *syntaxChecker) next(t Token) error {
+	switch c.state {
+	case expectValue, expectFirstValue:
+		if c.state == expectFirstValue && t.TokenType == RIGHT_BRACKET {
+			return c.close()
+		}
+		return c.value(t)
+	case expectFirstKey, expectKey:
+		if c.state == expectFirstKey && t.TokenType == RIGHT_BRACE {
+			return c.close()
+		}
+		if t.TokenType != STRING {
+			return fmt.Errorf("expected object key at %v, got %s", t.Pos, describeToken(t))
+		}
+		c.state = expectColon
+	case expectColon:
+		if t.TokenType != COLON {
+			return fmt.Errorf("expected colon at %v, got %s", t.Pos, describeToken(t))
+		}
+		c.state = expectValue
+	case expectCommaOrClose:
+		top := c.stack[len(c.stack)-1]
+		switch {
+		case t.TokenType == COMMA && top == LEFT_BRACE:
+			c.state = expectKey
+		case t.TokenType == COMMA:
+			c.state = expectValue
+		case t.TokenType == RIGHT_BRACE && top == LEFT_BRACE, t.TokenType == RIGHT_BRACKET && top == LEFT_BRACKET:
+			return c.close()
+		default:
+			return fmt.Errorf("expected comma or closing bracket at %v, got %s", t.Pos, describeToken(t))
+		}
+	case expectEOF:
+		if t.TokenType != EOF {
+			return fmt.Errorf("unexpected %s after top level value at %v", describeToken(t), t.Pos)
+		}
+	}
+	return nil
+}
+
+func (c *syntaxChecker) value(t Token) error {
+	switch t.TokenType {
+	case LEFT_BRACE:
+		c.stack = append(c.stack, LEFT_BRACE)
+		c.state = expectFirstKey
+	case LEFT_BRACKET:
+		c.stack = append(c.stack, LEFT_BRACKET)
+		c.state = expectFirstValue
+	case STRING, NUMBER, TRUE, FALSE, NULL:
+		c.valueDone()
+	case EOF:
+		return fmt.Errorf("unexpected end of input at %v", t.Pos)
+	default:
+		return fmt.Errorf("expected value at %v, got %s", t.Pos, describeToken(t))
+	}
+	return nil
+}
+
+func (c *syntaxChecker) close() error {
+	c.stack = c.stack[:len(c.stack)-1]
+	c.valueDone()
+	return nil
+}
+
+func (c *syntaxChecker) valueDone() {
+	if len(c.stack) == 0 {
+		c.state = expectEOF
+	} else {
+		c.state = expectCommaOrClose
+	}
+}
+
+func describeToken(t Token) string {
+	if t.TokenType == EOF {
+		return "end of input"
+	}
+	return tokenText(t)
+}
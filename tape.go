@@ -0,0 +1,152 @@
+package parser
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Tape is a flat index over the tokens of a document, in the spirit of
+// simdjson. Jump links every brace or bracket to its partner, so a subtree
+// can be skipped in constant time and a document can be queried many times
+// without walking it again.
+type Tape struct {
+	Tokens []Token
+	// Jump holds, for an opening brace or bracket, the index of the token
+	// that closes it and, for a closing one, the index of the token it
+	// closes. Unmatched ones get -1 and all other tokens their own index.
+	Jump []int
+	// open is the stack of unclosed tokens, kept for reuse
+	open []int
+}
+
+// NewTape lexes input and indexes its tokens. It does not check the
+// grammar beyond pairing brackets, see Parser.Parse for that.
+func NewTape(input []byte) (*Tape, error) {
+	tokens, err := NewLexerBytes(input).Tokenize()
+	if err != nil {
+		return nil, err
+	}
+	return BuildTape(tokens), nil
+}
+
+// BuildTape indexes tokens in a single pass. Each closing token is paired
+// with the innermost open one, even if their kinds differ; callers compare
+// the kinds to detect mismatches like "[}".
+func BuildTape(tokens []Token) *Tape {
+	t := &Tape{}
+	t.build(tokens)
+	return t
+}
+
+// build indexes tokens, reusing the memory of t
+func (t *Tape) build(tokens []Token) {
+	if cap(t.Jump) < len(tokens) {
+		t.Jump = make([]int, len(tokens))
+	}
+	jump := t.Jump[:len(tokens)]
+	open := t.open[:0]
+
+	for i, tok := range tokens {
+		switch tok.TokenType {
+		case LEFT_BRACE, LEFT_BRACKET:
+			jump[i] = -1
+			open = append(open, i)
+		case RIGHT_BRACE, RIGHT_BRACKET:
+			jump[i] = -1
+			if n := len(open); n > 0 {
+				jump[i] = open[n-1]
+				jump[open[n-1]] = i
+				open = open[:n-1]
+			}
+		default:
+			jump[i] = i
+		}
+	}
+
+	t.Tokens, t.Jump, t.open = tokens, jump, open
+}
+
+// Skip returns the index of the token that follows the value starting at
+// i, or -1 if the value is not closed.
+func (t *Tape) Skip(i int) int {
+	switch t.Tokens[i].TokenType {
+	case LEFT_BRACE, LEFT_BRACKET:
+		if t.Jump[i] < 0 {
+			return -1
+		}
+		return t.Jump[i] + 1
+	}
+	return i + 1
+}
+
+// Lookup resolves a JSON Pointer against the value starting at token 0 and
+// returns the index of the token that starts the value it refers to, or -1.
+// Siblings before the target are skipped through Jump without being
+// looked at. The tape must hold a valid document.
+func (t *Tape) Lookup(ptr string) int {
+	if ptr == "" {
+		return 0
+	}
+	if !strings.HasPrefix(ptr, "/") || len(t.Tokens) == 0 {
+		return -1
+	}
+
+	cur := 0
+	for _, tok := range strings.Split(ptr[1:], "/") {
+		tok = pointerUnescaper.Replace(tok)
+		switch t.Tokens[cur].TokenType {
+		case LEFT_BRACE:
+			cur = t.member(cur, tok)
+		case LEFT_BRACKET:
+			cur = t.element(cur, tok)
+		default:
+			return -1
+		}
+		if cur < 0 {
+			return -1
+		}
+	}
+	return cur
+}
+
+// member returns the index of the value of the last member named key of
+// the object at i
+func (t *Tape) member(i int, key string) int {
+	found := -1
+	for k := i + 1; k < t.Jump[i]; {
+		name, _ := unquote(t.Tokens[k].Value)
+		if name == key {
+			found = k + 2
+		}
+		// key, colon, value and the comma after it
+		k = t.Skip(k+2) + 1
+	}
+	return found
+}
+
+// element returns the index of the element of the array at i named by the
+// reference token tok
+func (t *Tape) element(i int, tok string) int {
+	n, ok := pointerIndex(tok, math.MaxInt)
+	if !ok {
+		return -1
+	}
+
+	k := i + 1
+	for ; n > 0 && k < t.Jump[i]; n-- {
+		k = t.Skip(k) + 1
+	}
+	if k >= t.Jump[i] {
+		return -1
+	}
+	return k
+}
+
+// Value decodes the value starting at token i.
+func (t *Tape) Value(i int) (*Value, error) {
+	if i < 0 || i >= len(t.Tokens) {
+		return nil, fmt.Errorf("token index %d out of range", i)
+	}
+	return buildValue(t.Tokens, &i)
+}
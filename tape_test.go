@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestBuildTape(t *testing.T) {
+	tape, err := NewTape([]byte(`{"a": [1, {}], "b": 2}`))
+	if err != nil {
+		t.Fatalf("error building tape %v", err)
+	}
+
+	// {  "a"  :  [  1  ,  {  }  ]  ,  "b"  :  2  }  EOF
+	want := []int{13, 1, 2, 8, 4, 5, 7, 6, 3, 9, 10, 11, 12, 0, 14}
+	if !reflect.DeepEqual(tape.Jump, want) {
+		t.Errorf("unexpected jumps %v", tape.Jump)
+	}
+
+	if tape.Skip(0) != 14 || tape.Skip(3) != 9 || tape.Skip(1) != 2 {
+		t.Errorf("unexpected skips %d %d %d", tape.Skip(0), tape.Skip(3), tape.Skip(1))
+	}
+}
+
+func TestBuildTapeUnbalanced(t *testing.T) {
+	tape, err := NewTape([]byte(`[[}`))
+	if err != nil {
+		t.Fatalf("error building tape %v", err)
+	}
+
+	// the brace closes the inner bracket, the outer one stays open
+	if tape.Jump[0] != -1 || tape.Jump[1] != 2 || tape.Skip(0) != -1 {
+		t.Errorf("unexpected jumps %v", tape.Jump)
+	}
+}
+
+func TestTapeLookup(t *testing.T) {
+	tape, err := NewTape([]byte(`{"a": [1, {"b": [true, null]}], "a~/": 3, "c": {"d": "x"}, "c": {"d": "y"}}`))
+	if err != nil {
+		t.Fatalf("error building tape %v", err)
+	}
+
+	cases := map[string]string{
+		"":          `{"a":[1,{"b":[true,null]}],"a~/":3,"c":{"d":"x"},"c":{"d":"y"}}`,
+		"/a/1/b/1":  "null",
+		"/a~0~1":    "3",
+		"/c/d":      `"y"`,
+		"/a/0":      "1",
+		"/a/2":      "",
+		"/a/01":     "",
+		"/missing":  "",
+		"/a/0/x":    "",
+		"no-prefix": "",
+	}
+
+	for ptr, want := range cases {
+		i := tape.Lookup(ptr)
+		if want == "" {
+			if i != -1 {
+				t.Errorf("%q: expected no match, got token %d", ptr, i)
+			}
+			continue
+		}
+
+		v, err := tape.Value(i)
+		if err != nil {
+			t.Fatalf("%q: error decoding %v", ptr, err)
+		}
+		if v.String() != want {
+			t.Errorf("%q: expected %s, got %s", ptr, want, v)
+		}
+	}
+}
+
+func TestParserUsesTape(t *testing.T) {
+	for _, s := range []string{`[1, [2, 3], {"a": [4]}]`, `{"a": {"b": {}}}`} {
+		p, _ := NewParser([]byte(s))
+		if ok, err := p.Parse(); !ok || err != nil {
+			t.Errorf("%s: unexpected error %v", s, err)
+		}
+		if p.Tape().Skip(0) != len(p.tokens)-1 {
+			t.Errorf("%s: expected the tape to span the document", s)
+		}
+	}
+
+	for _, s := range []string{`[1, 2}`, `{"a": [1}]`, `[[]`, `[]]`, `{"a" 1}`, `[1 2]`, `[1,]`} {
+		p, _ := NewParser([]byte(s))
+		if _, err := p.Parse(); err == nil {
+			t.Errorf("%s: error should have been raised", s)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package parser
+
+import (
+	"context"
+	"log/slog"
+)
+
+// Tracer receives what a Parser does, to debug the parsing of a specific
+// payload. A Parser without a tracer, the default, reports nothing.
+type Tracer interface {
+	// OnToken is called for every token the parser consumes, with its
+	// index in the token stream.
+	OnToken(t Token, index int)
+	// OnPush is called when an object or array is opened, with the depth
+	// after the push.
+	OnPush(open Token, depth int)
+	// OnPop is called when an object or array is closed, with the depth
+	// after the pop.
+	OnPop(close Token, depth int)
+	// OnError is called once with the error Parse returns and the token
+	// it stopped at.
+	OnError(err error, t Token)
+}
+
+// SetTracer makes r report its work to t, or nothing if t is nil.
+func (r *Parser) SetTracer(t Tracer) {
+	r.tracer = t
+}
+
+// NewSlogTracer returns a Tracer that logs every event at debug level.
+func NewSlogTracer(l *slog.Logger) Tracer {
+	return slogTracer{l}
+}
+
+type slogTracer struct {
+	l *slog.Logger
+}
+
+func (s slogTracer) OnToken(t Token, index int) {
+	s.l.LogAttrs(context.Background(), slog.LevelDebug, "token",
+		slog.Int("index", index), slog.String("token", describeToken(t)), slog.String("pos", t.Pos.String()))
+}
+
+func (s slogTracer) OnPush(open Token, depth int) {
+	s.l.LogAttrs(context.Background(), slog.LevelDebug, "push",
+		slog.String("token", describeToken(open)), slog.Int("depth", depth), slog.String("pos", open.Pos.String()))
+}
+
+func (s slogTracer) OnPop(close Token, depth int) {
+	s.l.LogAttrs(context.Background(), slog.LevelDebug, "pop",
+		slog.String("token", describeToken(close)), slog.Int("depth", depth), slog.String("pos", close.Pos.String()))
+}
+
+func (s slogTracer) OnError(err error, t Token) {
+	s.l.LogAttrs(context.Background(), slog.LevelDebug, "error",
+		slog.String("error", err.Error()), slog.String("token", describeToken(t)), slog.String("pos", t.Pos.String()))
+}
@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// traceRecorder writes every event as a line of text
+type traceRecorder struct {
+	events []string
+}
+
+func (r *traceRecorder) OnToken(t Token, index int) {
+	r.events = append(r.events, fmt.Sprintf("token %d %s", index, describeToken(t)))
+}
+
+func (r *traceRecorder) OnPush(open Token, depth int) {
+	r.events = append(r.events, fmt.Sprintf("push %s %d", open.Value, depth))
+}
+
+func (r *traceRecorder) OnPop(close Token, depth int) {
+	r.events = append(r.events, fmt.Sprintf("pop %s %d", close.Value, depth))
+}
+
+func (r *traceRecorder) OnError(err error, t Token) {
+	r.events = append(r.events, fmt.Sprintf("error %s", describeToken(t)))
+}
+
+func TestTracer(t *testing.T) {
+	p, err := NewParser([]byte(`{"a": [1, true]}`))
+	if err != nil {
+		t.Fatalf("error creating parser %v", err)
+	}
+
+	rec := &traceRecorder{}
+	p.SetTracer(rec)
+	if _, err := p.Parse(); err != nil {
+		t.Fatalf("error parsing %v", err)
+	}
+
+	want := []string{
+		"token 0 {",
+		"push { 1",
+		`token 1 "a"`,
+		"token 2 :",
+		"token 3 [",
+		"push [ 2",
+		"token 4 1",
+		"token 5 ,",
+		"token 6 true",
+		"token 7 ]",
+		"pop ] 1",
+		"token 8 }",
+		"pop } 0",
+		"token 9 end of input",
+	}
+	if !reflect.DeepEqual(rec.events, want) {
+		t.Errorf("expected %q, got %q", want, rec.events)
+	}
+}
+
+func TestTracerError(t *testing.T) {
+	p, _ := NewParser([]byte(`[1 2]`))
+
+	rec := &traceRecorder{}
+	p.SetTracer(rec)
+	if _, err := p.Parse(); err == nil {
+		t.Fatalf("error should have been raised")
+	}
+
+	if last := rec.events[len(rec.events)-1]; last != "error 2" {
+		t.Errorf("expected the error at the second number, got %q", last)
+	}
+}
+
+func TestSlogTracer(t *testing.T) {
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+
+	p, _ := NewParser([]byte(`[{}, 1,]`))
+	p.SetTracer(NewSlogTracer(logger))
+	p.Parse()
+
+	out := buf.String()
+	for _, want := range []string{"msg=token", "msg=push", "depth=1", "msg=error"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("expected %q in the log, got %s", want, out)
+		}
+	}
+}
+
+func TestParseIsSilent(t *testing.T) {
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout := os.Stdout
+	os.Stdout = w
+
+	for _, sample := range []string{`{"a": [[1], {"b": []}]}`, `[1,]`, `{"a" 1}`} {
+		if p, err := NewParser([]byte(sample)); err == nil {
+			p.Parse()
+		}
+	}
+
+	os.Stdout = stdout
+	w.Close()
+	out, _ := io.ReadAll(r)
+	if len(out) != 0 {
+		t.Errorf("expected no output, got %q", out)
+	}
+}
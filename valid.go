@@ -0,0 +1,113 @@
+package parser
+
+import (
+	"context"
+	"io"
+	"sync"
+	"unsafe"
+)
+
+// validChunk is the size of the buffer ValidReader reads into. It only
+// grows when a single token does not fit.
+const validChunk = 32 << 10
+
+// checkerPool keeps the container stacks of finished validations so that
+// Valid does not allocate one per call
+var checkerPool = sync.Pool{
+	New: func() any { return newSyntaxChecker() },
+}
+
+// Valid reports whether input is a single valid JSON document. Lexing and
+// checking happen in one pass over input without collecting tokens or
+// copying the input, so apart from growing a pooled container stack it
+// does not allocate.
+func Valid(input []byte) bool {
+	return validate(context.Background(), input, nil) == nil
+}
+
+// Validate is Valid returning why input is invalid. It shares the pooled
+// state of Valid, so a service can check many small payloads without
+// producing garbage for the valid ones.
+func Validate(input []byte) error {
+	return validate(context.Background(), input, nil)
+}
+
+// ValidReader is like Valid for a document read from rd, returning why it
+// is invalid. The document is read in chunks, so memory use depends on
+// the longest token rather than the size of the document.
+func ValidReader(rd io.Reader) error {
+	return ValidReaderContext(context.Background(), rd)
+}
+
+// ValidReaderContext is ValidReader, giving up with a ContextError once ctx
+// is done. The context is looked at between reads and every few thousand
+// tokens, so a Read that blocks is not interrupted; rd itself has to time
+// out for that.
+func ValidReaderContext(ctx context.Context, rd io.Reader) error {
+	return validate(ctx, make([]byte, 0, validChunk), rd)
+}
+
+// validate checks the document in buf, refilling buf from rd as tokens are
+// consumed. If rd is nil buf holds the whole document.
+func validate(ctx context.Context, buf []byte, rd io.Reader) error {
+	checker := checkerPool.Get().(*syntaxChecker)
+	defer checkerPool.Put(checker)
+	checker.reset()
+
+	lexer := Lexer{pos: Position{Line: 1, Col: 1}}
+	eof := rd == nil
+
+	for n := 1; ; n++ {
+		if n%checkEvery == 0 {
+			if err := checkContext(ctx, lexer.pos.Offset); err != nil {
+				return err
+			}
+		}
+
+		// tokens only live until the checker has seen them, so the lexer
+		// can look at buf directly
+		lexer.src = unsafe.String(unsafe.SliceData(buf), len(buf))
+
+		start := lexer.pos
+		t, err := lexer.nextBytes()
+
+		// a token that runs into the end of buf may continue after it
+		if !eof && (lexer.short || t.TokenType == EOF || err == nil && lexer.pos.Offset-lexer.base == len(buf)) {
+			lexer.pos = start
+			if err := checkContext(ctx, start.Offset); err != nil {
+				return err
+			}
+			if buf, err = refill(buf, start.Offset-lexer.base, rd); err == io.EOF {
+				eof = true
+			} else if err != nil {
+				return err
+			}
+			lexer.base = start.Offset
+			continue
+		}
+		if err != nil {
+			return err
+		}
+
+		if err := checker.next(t); err != nil {
+			return err
+		}
+		if t.TokenType == EOF {
+			return nil
+		}
+	}
+}
+
+// refill drops the bytes of buf before keep and reads more after the rest,
+// growing buf if the kept bytes already fill it
+func refill(buf []byte, keep int, rd io.Reader) ([]byte, error) {
+	n := copy(buf[:cap(buf)], buf[keep:])
+	if n == cap(buf) {
+		grown := make([]byte, n, 2*cap(buf))
+		copy(grown, buf[:n])
+		buf = grown
+	}
+
+	m, err := rd.Read(buf[n:cap(buf)])
+	return buf[:n+m], err
+}
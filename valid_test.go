@@ -0,0 +1,110 @@
+package parser
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestValidMatchesParse(t *testing.T) {
+	files, _ := filepath.Glob(filepath.Join(conformanceDir, "*.json"))
+	fixtures, _ := filepath.Glob("./main/test*/*.json")
+
+	for _, file := range append(files, fixtures...) {
+		content, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("error reading %s: %v", file, err)
+		}
+
+		want := parseDocument(content) == nil
+
+		if got := Valid(content); got != want {
+			t.Errorf("%s: Valid returned %v, Parse %v", file, got, want)
+		}
+
+		// one byte per read puts every token across a chunk boundary
+		err = ValidReader(iotest.OneByteReader(bytes.NewReader(content)))
+		if got := err == nil; got != want {
+			t.Errorf("%s: ValidReader returned %v, Parse %v", file, err, want)
+		}
+	}
+}
+
+func TestValidReaderLongToken(t *testing.T) {
+	long := strings.Repeat("x", 3*validChunk)
+	sample := `{"a": "` + long + `", "b": 1` + strings.Repeat("0", validChunk) + `}`
+
+	if err := ValidReader(strings.NewReader(sample)); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+
+	if err := ValidReader(strings.NewReader(sample[:len(sample)-1])); err == nil {
+		t.Errorf("error should have been raised")
+	}
+}
+
+func TestValidReaderErrors(t *testing.T) {
+	samples := []string{
+		"",
+		"   ",
+		"[1, 2",
+		`{"a": tru`,
+		`{"a": 1.}`,
+		`{"a" 1}`,
+		`["\x"]`,
+		"[1] 2",
+	}
+
+	for _, s := range samples {
+		if err := ValidReader(iotest.HalfReader(strings.NewReader(s))); err == nil {
+			t.Errorf("%q: error should have been raised", s)
+		}
+		if Valid([]byte(s)) {
+			t.Errorf("%q: expected invalid", s)
+		}
+	}
+}
+
+func TestValidReaderReadError(t *testing.T) {
+	err := ValidReader(iotest.TimeoutReader(iotest.OneByteReader(strings.NewReader("[1, 2]"))))
+
+	if err != iotest.ErrTimeout {
+		t.Errorf("expected read error, got %v", err)
+	}
+}
+
+func TestValidAllocations(t *testing.T) {
+	input := []byte(`{"id": 12, "tags": ["a", "b\"c"], "nested": {"ok": true, "n": null}, "x": -0.5e10}`)
+
+	allocs := testing.AllocsPerRun(100, func() {
+		if !Valid(input) {
+			t.Fatalf("expected valid")
+		}
+	})
+
+	if allocs != 0 {
+		t.Errorf("expected no allocations, got %v", allocs)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	if err := Validate([]byte(`{"a": [1, 2]}`)); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if err := Validate([]byte(`{"a": [1, 2}`)); err == nil {
+		t.Errorf("error should have been raised")
+	}
+
+	input := []byte(`{"id": 12, "tags": ["a", "b\"c"], "nested": {"ok": true, "n": null}, "x": -0.5e10}`)
+	allocs := testing.AllocsPerRun(100, func() {
+		if err := Validate(input); err != nil {
+			t.Fatalf("unexpected error %v", err)
+		}
+	})
+	if allocs != 0 {
+		t.Errorf("expected no allocations, got %v", allocs)
+	}
+}
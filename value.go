@@ -0,0 +1,358 @@
+package parser
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+type Kind int
+
+const (
+	NULL_VALUE Kind = iota
+	BOOL_VALUE
+	NUMBER_VALUE
+	STRING_VALUE
+	ARRAY_VALUE
+	OBJECT_VALUE
+)
+
+func (k Kind) String() string {
+	switch k {
+	case NULL_VALUE:
+		return "null"
+	case BOOL_VALUE:
+		return "boolean"
+	case NUMBER_VALUE:
+		return "number"
+	case STRING_VALUE:
+		return "string"
+	case ARRAY_VALUE:
+		return "array"
+	case OBJECT_VALUE:
+		return "object"
+	}
+	return "unknown"
+}
+
+// Value is a decoded JSON value together with the position it was read from.
+// Number keeps the literal as written so callers can tell 1 from 1.0.
+type Value struct {
+	Kind    Kind
+	Pos     Position
+	Bool    bool
+	Number  string
+	Str     string
+	Elems   []*Value
+	Members []Member
+}
+
+// Member is a single key/value pair of an object, in document order.
+type Member struct {
+	Key    string
+	KeyPos Position
+	Value  *Value
+}
+
+// ParseValue validates input and decodes it into a value tree.
+func ParseValue(input []byte) (*Value, error) {
+	return ParseValueContext(context.Background(), input)
+}
+
+// ParseValueContext is ParseValue, giving up with a ContextError once ctx
+// is done.
+func ParseValueContext(ctx context.Context, input []byte) (*Value, error) {
+	p, err := NewParserContext(ctx, input)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := p.ParseContext(ctx); err != nil {
+		return nil, err
+	}
+
+	if err := checkContext(ctx, len(input)); err != nil {
+		return nil, err
+	}
+	return p.Value()
+}
+
+// Value builds the value tree from the tokens of an already parsed document.
+func (r *Parser) Value() (*Value, error) {
+	idx := 0
+	v, err := buildValue(r.tokens, &idx)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.tokens[idx].TokenType != EOF {
+		return nil, fmt.Errorf("unexpected token after value at %v: %s", r.tokens[idx].Pos, r.tokens[idx].Value)
+	}
+
+	return v, nil
+}
+
+func buildValue(tokens []Token, idx *int) (*Value, error) {
+	cur := tokens[*idx]
+	*idx++
+
+	switch cur.TokenType {
+	case NULL, TRUE, FALSE, NUMBER, STRING:
+		return scalarValue(cur)
+	case LEFT_BRACKET:
+		v := &Value{Kind: ARRAY_VALUE, Pos: cur.Pos, Elems: []*Value{}}
+		if tokens[*idx].TokenType == RIGHT_BRACKET {
+			*idx++
+			return v, nil
+		}
+		for {
+			elem, err := buildValue(tokens, idx)
+			if err != nil {
+				return nil, err
+			}
+			v.Elems = append(v.Elems, elem)
+
+			next := tokens[*idx]
+			*idx++
+			if next.TokenType == RIGHT_BRACKET {
+				return v, nil
+			}
+			if next.TokenType != COMMA {
+				return nil, fmt.Errorf("expected comma or closing bracket at %v, got %s", next.Pos, next.Value)
+			}
+		}
+	case LEFT_BRACE:
+		v := &Value{Kind: OBJECT_VALUE, Pos: cur.Pos, Members: []Member{}}
+		if tokens[*idx].TokenType == RIGHT_BRACE {
+			*idx++
+			return v, nil
+		}
+		for {
+			key := tokens[*idx]
+			if key.TokenType != STRING || tokens[*idx+1].TokenType != COLON {
+				return nil, fmt.Errorf("expected key at %v, got %s", key.Pos, key.Value)
+			}
+			*idx += 2
+
+			k, err := unquote(key.Value)
+			if err != nil {
+				return nil, fmt.Errorf("%v at %v", err, key.Pos)
+			}
+
+			val, err := buildValue(tokens, idx)
+			if err != nil {
+				return nil, err
+			}
+			v.Members = append(v.Members, Member{Key: k, KeyPos: key.Pos, Value: val})
+
+			next := tokens[*idx]
+			*idx++
+			if next.TokenType == RIGHT_BRACE {
+				return v, nil
+			}
+			if next.TokenType != COMMA {
+				return nil, fmt.Errorf("expected comma or closing brace at %v, got %s", next.Pos, next.Value)
+			}
+		}
+	case EOF:
+		return nil, fmt.Errorf("unexpected end of input at %v", cur.Pos)
+	default:
+		return nil, fmt.Errorf("expected value at %v, got %s", cur.Pos, cur.Value)
+	}
+}
+
+// scalarValue decodes a token that is a complete value on its own
+func scalarValue(cur Token) (*Value, error) {
+	switch cur.TokenType {
+	case NULL:
+		return &Value{Kind: NULL_VALUE, Pos: cur.Pos}, nil
+	case TRUE, FALSE:
+		return &Value{Kind: BOOL_VALUE, Pos: cur.Pos, Bool: cur.TokenType == TRUE}, nil
+	case NUMBER:
+		return &Value{Kind: NUMBER_VALUE, Pos: cur.Pos, Number: cur.Value}, nil
+	case STRING:
+		s, err := unquote(cur.Value)
+		if err != nil {
+			return nil, fmt.Errorf("%v at %v", err, cur.Pos)
+		}
+		return &Value{Kind: STRING_VALUE, Pos: cur.Pos, Str: s}, nil
+	}
+	return nil, fmt.Errorf("expected value at %v, got %s", cur.Pos, cur.Value)
+}
+
+// Get returns the value of the last member named key, or nil if v is not
+// an object or has no such member.
+func (v *Value) Get(key string) *Value {
+	if v == nil || v.Kind != OBJECT_VALUE {
+		return nil
+	}
+	for i := len(v.Members) - 1; i >= 0; i-- {
+		if v.Members[i].Key == key {
+			return v.Members[i].Value
+		}
+	}
+	return nil
+}
+
+// Float returns the numeric value of a number.
+func (v *Value) Float() float64 {
+	f, _ := strconv.ParseFloat(v.Number, 64)
+	return f
+}
+
+// String returns the compact JSON encoding of v.
+func (v *Value) String() string {
+	var sb strings.Builder
+	v.write(&sb)
+	return sb.String()
+}
+
+// MarshalJSON implements json.Marshaler so a value tree can be passed to
+// encoding/json, e.g. json.MarshalIndent.
+func (v *Value) MarshalJSON() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+func (v *Value) write(sb *strings.Builder) {
+	switch v.Kind {
+	case NULL_VALUE:
+		sb.WriteString("null")
+	case BOOL_VALUE:
+		sb.WriteString(strconv.FormatBool(v.Bool))
+	case NUMBER_VALUE:
+		sb.WriteString(v.Number)
+	case STRING_VALUE:
+		sb.WriteString(quote(v.Str))
+	case ARRAY_VALUE:
+		sb.WriteByte('[')
+		for i, e := range v.Elems {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			e.write(sb)
+		}
+		sb.WriteByte(']')
+	case OBJECT_VALUE:
+		sb.WriteByte('{')
+		for i, m := range v.Members {
+			if i > 0 {
+				sb.WriteByte(',')
+			}
+			sb.WriteString(quote(m.Key))
+			sb.WriteByte(':')
+			m.Value.write(sb)
+		}
+		sb.WriteByte('}')
+	}
+}
+
+// unquote decodes the escape sequences of a raw string token value. Bytes
+// that are not valid UTF-8 become U+FFFD, one per byte, as with
+// encoding/json.
+func unquote(raw string) (string, error) {
+	if strings.IndexByte(raw, '\\') < 0 && utf8.ValidString(raw) {
+		return raw, nil
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		if c >= utf8.RuneSelf {
+			r, size := utf8.DecodeRuneInString(raw[i:])
+			sb.WriteRune(r)
+			i += size - 1
+			continue
+		}
+		if c != '\\' {
+			sb.WriteByte(c)
+			continue
+		}
+		i++
+		if i >= len(raw) {
+			return "", fmt.Errorf("unterminated escape sequence")
+		}
+		switch raw[i] {
+		case '"', '\\', '/':
+			sb.WriteByte(raw[i])
+		case 'b':
+			sb.WriteByte('\b')
+		case 'f':
+			sb.WriteByte('\f')
+		case 'n':
+			sb.WriteByte('\n')
+		case 'r':
+			sb.WriteByte('\r')
+		case 't':
+			sb.WriteByte('\t')
+		case 'u':
+			r1, ok := hex4(raw[i+1:])
+			if !ok {
+				return "", fmt.Errorf("invalid unicode escape")
+			}
+			i += 4
+			if utf16.IsSurrogate(r1) {
+				// a high surrogate must be followed by an escaped low one
+				if strings.HasPrefix(raw[i+1:], `\u`) {
+					r2, ok := hex4(raw[i+3:])
+					if dec := utf16.DecodeRune(r1, r2); ok && dec != utf8.RuneError {
+						sb.WriteRune(dec)
+						i += 6
+						continue
+					}
+				}
+				r1 = utf8.RuneError
+			}
+			sb.WriteRune(r1)
+		default:
+			return "", fmt.Errorf("invalid escape sequence: \\%c", raw[i])
+		}
+	}
+	return sb.String(), nil
+}
+
+func hex4(s string) (rune, bool) {
+	if len(s) < 4 {
+		return 0, false
+	}
+	n, err := strconv.ParseUint(s[:4], 16, 32)
+	if err != nil {
+		return 0, false
+	}
+	return rune(n), true
+}
+
+// quote encodes s as a JSON string, escaping only what JSON requires
+func quote(s string) string {
+	var sb strings.Builder
+	sb.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch c {
+		case '"':
+			sb.WriteString(`\"`)
+		case '\\':
+			sb.WriteString(`\\`)
+		case '\b':
+			sb.WriteString(`\b`)
+		case '\f':
+			sb.WriteString(`\f`)
+		case '\n':
+			sb.WriteString(`\n`)
+		case '\r':
+			sb.WriteString(`\r`)
+		case '\t':
+			sb.WriteString(`\t`)
+		default:
+			if c < 0x20 {
+				fmt.Fprintf(&sb, `\u%04x`, c)
+			} else {
+				sb.WriteByte(c)
+			}
+		}
+	}
+	sb.WriteByte('"')
+	return sb.String()
+}
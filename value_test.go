@@ -0,0 +1,90 @@
+package parser
+
+import "testing"
+
+func TestParseValue(t *testing.T) {
+	sample := []byte("{\"a\": [1, 2.5, true, null], \"b\": {\"c\": \"d\\n\\u00e9\\ud83d\\ude00\"}, \"e\": \"\"}")
+
+	v, err := ParseValue(sample)
+	if err != nil {
+		t.Fatalf("error parsing %v", err)
+	}
+
+	if v.Kind != OBJECT_VALUE || len(v.Members) != 3 {
+		t.Fatalf("expected object with 3 members, got %v", v)
+	}
+
+	a := v.Get("a")
+	if a.Kind != ARRAY_VALUE || len(a.Elems) != 4 || a.Elems[1].Number != "2.5" || !a.Elems[2].Bool {
+		t.Errorf("unexpected array %v", a)
+	}
+
+	if s := v.Get("b").Get("c").Str; s != "d\né😀" {
+		t.Errorf("unexpected string %q", s)
+	}
+
+	if s := v.Get("e"); s.Kind != STRING_VALUE || s.Str != "" {
+		t.Errorf("expected empty string, got %v", s)
+	}
+}
+
+func TestParseValuePositions(t *testing.T) {
+	sample := []byte("{\n  \"a\": 1,\n  \"b\": [true]\n}")
+
+	v, err := ParseValue(sample)
+	if err != nil {
+		t.Fatalf("error parsing %v", err)
+	}
+
+	if p := v.Members[1].KeyPos; p.Line != 3 || p.Col != 3 {
+		t.Errorf("unexpected key position %v", p)
+	}
+
+	if p := v.Get("b").Elems[0].Pos; p.Line != 3 || p.Col != 9 {
+		t.Errorf("unexpected value position %v", p)
+	}
+}
+
+func TestValueString(t *testing.T) {
+	sample := []byte("{ \"a\" : [ 1 , \"x\\\"y\" ] , \"b\" : { } }")
+
+	v, err := ParseValue(sample)
+	if err != nil {
+		t.Fatalf("error parsing %v", err)
+	}
+
+	if s := v.String(); s != "{\"a\":[1,\"x\\\"y\"],\"b\":{}}" {
+		t.Errorf("unexpected encoding %s", s)
+	}
+}
+
+func TestParseValueEmpty(t *testing.T) {
+	_, err := ParseValue([]byte("  "))
+
+	if err == nil {
+		t.Errorf("error should have been raised")
+	}
+}
+
+func TestParseValueInvalidUTF8(t *testing.T) {
+	v, err := ParseValue([]byte("{\"k\\u00e9\xff\": \"a\xc3\\n\xf0\x9f\x98\"}"))
+	if err != nil {
+		t.Fatalf("error parsing %v", err)
+	}
+
+	m := v.Members[0]
+	if m.Key != "ké�" || m.Value.Str != "a�\n���" {
+		t.Errorf("unexpected decoding %q: %q", m.Key, m.Value.Str)
+	}
+}
+
+func TestParseValueNumberOutOfRange(t *testing.T) {
+	v, err := ParseValue([]byte("[1e400, -1e-400]"))
+	if err != nil {
+		t.Fatalf("error parsing %v", err)
+	}
+
+	if v.Elems[0].Number != "1e400" || v.Elems[1].Number != "-1e-400" {
+		t.Errorf("unexpected numbers %v", v)
+	}
+}
@@ -0,0 +1,149 @@
+package parser
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Handler receives the events of a document as Walk reads it. Returning
+// SkipValue from OnObjectStart, OnArrayStart or OnKey skips the container
+// or the member's value without further events, SkipAll stops the walk
+// and any other error aborts it and is returned by Walk.
+type Handler interface {
+	OnObjectStart(pos Position) error
+	// OnKey is called with the decoded key of each object member, before
+	// the events of its value.
+	OnKey(key string, pos Position) error
+	OnString(s string, pos Position) error
+	// OnNumber is called with the literal as written in the input.
+	OnNumber(literal string, pos Position) error
+	OnBool(b bool, pos Position) error
+	OnNull(pos Position) error
+	OnArrayStart(pos Position) error
+	// OnEnd closes the innermost object or array that was not skipped.
+	OnEnd(pos Position) error
+}
+
+var (
+	// SkipValue makes Walk skip the value whose event returned it.
+	SkipValue = errors.New("skip this value")
+	// SkipAll makes Walk stop without an error. The rest of the document
+	// is not read, so it is not validated either.
+	SkipAll = errors.New("skip everything")
+)
+
+// NopHandler ignores every event. Embed it to implement only the events
+// you need.
+type NopHandler struct{}
+
+func (NopHandler) OnObjectStart(Position) error    { return nil }
+func (NopHandler) OnKey(string, Position) error    { return nil }
+func (NopHandler) OnString(string, Position) error { return nil }
+func (NopHandler) OnNumber(string, Position) error { return nil }
+func (NopHandler) OnBool(bool, Position) error     { return nil }
+func (NopHandler) OnNull(Position) error           { return nil }
+func (NopHandler) OnArrayStart(Position) error     { return nil }
+func (NopHandler) OnEnd(Position) error            { return nil }
+
+// Walk validates input and reports its values to h in document order,
+// without building tokens or a value tree.
+func Walk(input []byte, h Handler) error {
+	return walk(NewLexerBytes(input), h)
+}
+
+// WalkReader is like Walk for a document read from rd, so memory use does
+// not depend on the size of the document.
+func WalkReader(rd io.Reader, h Handler) error {
+	return walk(NewLexer(bufio.NewReader(rd)), h)
+}
+
+func walk(lexer *Lexer, h Handler) error {
+	checker := newSyntaxChecker()
+
+	// skipping is the depth inside a skipped container, skipNext is set
+	// when a key asked to skip the member's value
+	skipping := 0
+	skipNext := false
+
+	for {
+		t, err := lexer.Next()
+		if err != nil {
+			return err
+		}
+
+		isKey := checker.state == expectFirstKey || checker.state == expectKey
+		if err := checker.next(t); err != nil {
+			return err
+		}
+
+		switch t.TokenType {
+		case EOF:
+			return nil
+		case COMMA, COLON:
+			continue
+		}
+
+		if skipping > 0 {
+			switch t.TokenType {
+			case LEFT_BRACE, LEFT_BRACKET:
+				skipping++
+			case RIGHT_BRACE, RIGHT_BRACKET:
+				skipping--
+			}
+			continue
+		}
+
+		if skipNext {
+			skipNext = false
+			if t.TokenType == LEFT_BRACE || t.TokenType == LEFT_BRACKET {
+				skipping = 1
+			}
+			continue
+		}
+
+		err = emit(h, t, isKey)
+		switch {
+		case err == SkipValue:
+			switch {
+			case t.TokenType == LEFT_BRACE || t.TokenType == LEFT_BRACKET:
+				skipping = 1
+			case isKey:
+				skipNext = true
+			}
+		case err == SkipAll:
+			return nil
+		case err != nil:
+			return err
+		}
+	}
+}
+
+// emit calls the handler method for a single token
+func emit(h Handler, t Token, isKey bool) error {
+	switch t.TokenType {
+	case LEFT_BRACE:
+		return h.OnObjectStart(t.Pos)
+	case LEFT_BRACKET:
+		return h.OnArrayStart(t.Pos)
+	case RIGHT_BRACE, RIGHT_BRACKET:
+		return h.OnEnd(t.Pos)
+	case STRING:
+		s, err := unquote(t.Value)
+		if err != nil {
+			return fmt.Errorf("%v at %v", err, t.Pos)
+		}
+		if isKey {
+			return h.OnKey(s, t.Pos)
+		}
+		return h.OnString(s, t.Pos)
+	case NUMBER:
+		return h.OnNumber(t.Value, t.Pos)
+	case TRUE, FALSE:
+		return h.OnBool(t.TokenType == TRUE, t.Pos)
+	case NULL:
+		return h.OnNull(t.Pos)
+	}
+	return nil
+}
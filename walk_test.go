@@ -0,0 +1,118 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// recorder writes every event as a short string
+type recorder struct {
+	events []string
+	skip   map[string]bool
+}
+
+func (r *recorder) add(event string) error {
+	r.events = append(r.events, event)
+	if r.skip[event] {
+		return SkipValue
+	}
+	return nil
+}
+
+func (r *recorder) OnObjectStart(Position) error        { return r.add("{") }
+func (r *recorder) OnKey(k string, _ Position) error    { return r.add("key " + k) }
+func (r *recorder) OnString(s string, _ Position) error { return r.add("string " + s) }
+func (r *recorder) OnNumber(n string, _ Position) error { return r.add("number " + n) }
+func (r *recorder) OnBool(b bool, _ Position) error     { return r.add(fmt.Sprint("bool ", b)) }
+func (r *recorder) OnNull(Position) error               { return r.add("null") }
+func (r *recorder) OnArrayStart(Position) error         { return r.add("[") }
+func (r *recorder) OnEnd(Position) error                { return r.add("end") }
+
+const walkSample = `{"a": [1, "x\ny", true], "b": {"c": null, "d": [{}]}, "e": false}`
+
+func TestWalk(t *testing.T) {
+	r := &recorder{}
+	if err := Walk([]byte(walkSample), r); err != nil {
+		t.Fatalf("error walking %v", err)
+	}
+
+	want := "{|key a|[|number 1|string x\ny|bool true|end|key b|{|key c|null|key d|[|{|end|end|end|key e|bool false|end"
+	if got := strings.Join(r.events, "|"); got != want {
+		t.Errorf("unexpected events\n%s\n%s", got, want)
+	}
+}
+
+func TestWalkSkip(t *testing.T) {
+	r := &recorder{skip: map[string]bool{"key a": true, "key d": true, "{": false}}
+	if err := WalkReader(strings.NewReader(walkSample), r); err != nil {
+		t.Fatalf("error walking %v", err)
+	}
+
+	want := "{|key a|key b|{|key c|null|key d|end|key e|bool false|end"
+	if got := strings.Join(r.events, "|"); got != want {
+		t.Errorf("unexpected events\n%s\n%s", got, want)
+	}
+}
+
+func TestWalkSkipContainer(t *testing.T) {
+	r := &recorder{skip: map[string]bool{"[": true}}
+	if err := Walk([]byte(`[[1, [2]], 3]`), r); err != nil {
+		t.Fatalf("error walking %v", err)
+	}
+
+	// the outer array is skipped as a whole, so nothing else is reported
+	if got := strings.Join(r.events, "|"); got != "[" {
+		t.Errorf("unexpected events %s", got)
+	}
+}
+
+type stopAt struct {
+	NopHandler
+	key  string
+	err  error
+	seen []string
+}
+
+func (s *stopAt) OnKey(k string, _ Position) error {
+	s.seen = append(s.seen, k)
+	if k == s.key {
+		return s.err
+	}
+	return nil
+}
+
+func TestWalkStop(t *testing.T) {
+	// SkipAll stops early, even before invalid input
+	h := &stopAt{key: "b", err: SkipAll}
+	if err := Walk([]byte(`{"a": 1, "b": 2, "c": 3,,,`), h); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	if len(h.seen) != 2 {
+		t.Errorf("expected to stop at b, saw %v", h.seen)
+	}
+
+	abort := errors.New("abort")
+	h = &stopAt{key: "missing", err: abort}
+	if err := Walk([]byte(walkSample), h); err != nil {
+		t.Errorf("unexpected error %v", err)
+	}
+	h = &stopAt{key: "d", err: abort}
+	if err := Walk([]byte(walkSample), h); err != abort {
+		t.Errorf("expected handler error, got %v", err)
+	}
+}
+
+func TestWalkInvalid(t *testing.T) {
+	samples := []string{`{"a": [1, 2,]}`, `{"a" 1}`, `[1] [2]`, `{"a": 1`, ``}
+
+	for _, s := range samples {
+		if err := Walk([]byte(s), NopHandler{}); err == nil {
+			t.Errorf("%s: error should have been raised", s)
+		}
+		if err := WalkReader(strings.NewReader(s), NopHandler{}); err == nil {
+			t.Errorf("%s: error should have been raised", s)
+		}
+	}
+}